@@ -0,0 +1,26 @@
+//go:build !nofbink
+// +build !nofbink
+
+package main
+
+import (
+	"fmt"
+	"os/exec"
+
+	"github.com/olup/kobowriter/screener"
+	"github.com/olup/kobowriter/screener/fbink"
+)
+
+func openFbink() (screener.Display, error) {
+	// kill all nickel related stuff. Will need a reboot to find back the usual
+	fmt.Println("Killing XCSoar programs ...")
+	exec.Command("killall", "-s", "SIGKILL", "KoboMenu").Run()
+
+	// rotate screen
+	fmt.Println("Rotate screen ...")
+	exec.Command(`fbdepth`, `--rota`, `2`).Run()
+
+	// initialise fbink
+	fmt.Println("Init FBInk ...")
+	return fbink.Open(), nil
+}
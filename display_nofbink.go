@@ -0,0 +1,15 @@
+//go:build nofbink
+// +build nofbink
+
+package main
+
+import (
+	"errors"
+
+	"github.com/olup/kobowriter/screener"
+)
+
+// desktop builds cannot link FBInk, see the desktop target of the Makefile
+func openFbink() (screener.Display, error) {
+	return nil, errors.New("built without fbink, use -display terminal")
+}
@@ -0,0 +1,272 @@
+package event
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// commands that can be bound to keys, see views for their implementation
+var Commands = []string{
+	"open-menu",
+	"insert-date",
+	"full-refresh",
+	"toggle-status-bar",
+	"sprint-menu",
+	"save",
+	"move-left",
+	"move-right",
+	"move-up",
+	"move-down",
+	"page-up",
+	"page-down",
+	"move-word-left",
+	"move-word-right",
+	"line-start",
+	"line-end",
+	"document-start",
+	"document-end",
+	"paragraph-up",
+	"paragraph-down",
+	"select-left",
+	"select-right",
+	"select-up",
+	"select-down",
+	"select-word-left",
+	"select-word-right",
+	"select-page-up",
+	"select-page-down",
+	"select-line-start",
+	"select-line-end",
+	"select-document-start",
+	"select-document-end",
+	"select-all",
+	"cut",
+	"copy",
+	"paste",
+	"open-clipboard",
+	"delete-backward",
+	"delete-forward",
+	"delete-word-backward",
+	"delete-word-forward",
+	"delete-line-end",
+	"delete-paragraph",
+	"newline",
+	"undo",
+	"redo",
+	"search",
+	"replace",
+	"search-next",
+	"search-previous",
+	"replace-all",
+	"toggle-regexp",
+	"toggle-case",
+	"search-documents",
+	"menu-up",
+	"menu-down",
+	"menu-select",
+}
+
+// unbinds a default binding when used as a command in the config
+const NoCommand = "none"
+
+var DefaultKeybindings = map[string]string{
+	"KEY_ESC":              "open-menu",
+	"KEY_F1":               "insert-date",
+	"KEY_F12":              "full-refresh",
+	"KEY_F2":               "toggle-status-bar",
+	"KEY_F5":               "sprint-menu",
+	"Ctrl+s":               "save",
+	"KEY_LEFT":             "move-left",
+	"KEY_RIGHT":            "move-right",
+	"KEY_UP":               "move-up",
+	"KEY_DOWN":             "move-down",
+	"Ctrl+KEY_UP":          "page-up",
+	"Ctrl+KEY_DOWN":        "page-down",
+	"KEY_PAGEUP":           "page-up",
+	"KEY_PAGEDOWN":         "page-down",
+	"Ctrl+KEY_LEFT":        "move-word-left",
+	"Ctrl+KEY_RIGHT":       "move-word-right",
+	"KEY_HOME":             "line-start",
+	"KEY_END":              "line-end",
+	"Ctrl+KEY_HOME":        "document-start",
+	"Ctrl+KEY_END":         "document-end",
+	"Alt+KEY_UP":           "paragraph-up",
+	"Alt+KEY_DOWN":         "paragraph-down",
+	"Shift+KEY_LEFT":       "select-left",
+	"Shift+KEY_RIGHT":      "select-right",
+	"Shift+KEY_UP":         "select-up",
+	"Shift+KEY_DOWN":       "select-down",
+	"Shift+Ctrl+KEY_LEFT":  "select-word-left",
+	"Shift+Ctrl+KEY_RIGHT": "select-word-right",
+	"Shift+Ctrl+KEY_UP":    "select-page-up",
+	"Shift+Ctrl+KEY_DOWN":  "select-page-down",
+	"Shift+KEY_PAGEUP":     "select-page-up",
+	"Shift+KEY_PAGEDOWN":   "select-page-down",
+	"Shift+KEY_HOME":       "select-line-start",
+	"Shift+KEY_END":        "select-line-end",
+	"Shift+Ctrl+KEY_HOME":  "select-document-start",
+	"Shift+Ctrl+KEY_END":   "select-document-end",
+	"Ctrl+a":               "select-all",
+	"Ctrl+x":               "cut",
+	"Ctrl+c":               "copy",
+	"Ctrl+v":               "paste",
+	"Shift+Ctrl+v":         "open-clipboard",
+	"KEY_BACKSPACE":        "delete-backward",
+	"KEY_DEL":              "delete-forward",
+	"Ctrl+KEY_BACKSPACE":   "delete-word-backward",
+	"Ctrl+KEY_DEL":         "delete-word-forward",
+	"Ctrl+k":               "delete-line-end",
+	"Shift+Ctrl+k":         "delete-paragraph",
+	"KEY_ENTER":            "newline",
+	"Ctrl+z":               "undo",
+	"Ctrl+y":               "redo",
+	"Ctrl+f":               "search",
+	"Ctrl+h":               "replace",
+	"KEY_F3":               "search-next",
+	"Shift+KEY_F3":         "search-previous",
+	"Ctrl+g":               "search-next",
+	"Shift+Ctrl+g":         "search-previous",
+	"Alt+KEY_ENTER":        "replace-all",
+	"Alt+r":                "toggle-regexp",
+	"Alt+c":                "toggle-case",
+	"Shift+Ctrl+f":         "search-documents",
+	"Menu+KEY_UP":          "menu-up",
+	"Menu+KEY_DOWN":        "menu-down",
+	"Menu+KEY_ENTER":       "menu-select",
+}
+
+// Binding is a key with the modifiers that must be held. Menus have their own
+// bindings, written with the Menu prefix, so that keys can mean different
+// things while writing and while browsing a menu.
+type Binding struct {
+	Menu  bool
+	Ctrl  bool
+	Alt   bool
+	AltGr bool
+	Shift bool
+	Meta  bool
+	Key   string
+}
+
+// ParseBinding reads bindings like "Ctrl+Shift+KEY_F1" or "Alt+z"
+func ParseBinding(raw string) (Binding, error) {
+	binding := Binding{}
+
+	parts := strings.Split(raw, "+")
+	key := parts[len(parts)-1]
+	modifiers := parts[:len(parts)-1]
+	// the plus key itself, like in "Ctrl++"
+	if key == "" && len(parts) > 1 && parts[len(parts)-2] == "" {
+		key = "+"
+		modifiers = parts[:len(parts)-2]
+	}
+	if key == "" {
+		return binding, fmt.Errorf("binding %q has no key", raw)
+	}
+
+	for _, modifier := range modifiers {
+		switch strings.ToLower(modifier) {
+		case "menu":
+			binding.Menu = true
+		case "ctrl":
+			binding.Ctrl = true
+		case "alt":
+			binding.Alt = true
+		case "altgr":
+			binding.AltGr = true
+		case "shift":
+			binding.Shift = true
+		case "meta", "super":
+			binding.Meta = true
+		default:
+			return binding, fmt.Errorf("binding %q has an unknown modifier %q", raw, modifier)
+		}
+	}
+
+	if !strings.HasPrefix(key, "KEY_") {
+		key = strings.ToLower(key)
+	}
+	binding.Key = key
+
+	return binding, nil
+}
+
+func bindingOf(e KeyEvent, menu bool) Binding {
+	key := e.KeyValue
+	if !strings.HasPrefix(key, "KEY_") {
+		key = strings.ToLower(key)
+	}
+	return Binding{
+		Menu:  menu,
+		Ctrl:  e.IsCtrl(),
+		Alt:   e.IsAlt(),
+		AltGr: e.IsAltGr(),
+		Shift: e.IsShift(),
+		Meta:  e.IsMeta(),
+		Key:   key,
+	}
+}
+
+var bindingsLock sync.RWMutex
+var keybindings = map[Binding]string{}
+
+func init() {
+	SetKeybindings(nil)
+}
+
+// SetKeybindings applies the bindings of the config over the default ones
+func SetKeybindings(custom map[string]string) (errs []error) {
+	bindings := map[Binding]string{}
+
+	for raw, command := range DefaultKeybindings {
+		binding, _ := ParseBinding(raw)
+		bindings[binding] = command
+	}
+
+	for raw, command := range custom {
+		binding, err := ParseBinding(raw)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if command == NoCommand {
+			delete(bindings, binding)
+			continue
+		}
+		if !isCommand(command) {
+			errs = append(errs, fmt.Errorf("binding %q: unknown command %q", raw, command))
+			continue
+		}
+		bindings[binding] = command
+	}
+
+	bindingsLock.Lock()
+	defer bindingsLock.Unlock()
+	keybindings = bindings
+
+	return errs
+}
+
+func isCommand(name string) bool {
+	for _, command := range Commands {
+		if command == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Command returns the command bound to the event while writing, if any
+func Command(e KeyEvent) string {
+	bindingsLock.RLock()
+	defer bindingsLock.RUnlock()
+	return keybindings[bindingOf(e, false)]
+}
+
+// MenuCommand returns the command bound to the event in menus, if any
+func MenuCommand(e KeyEvent) string {
+	bindingsLock.RLock()
+	defer bindingsLock.RUnlock()
+	return keybindings[bindingOf(e, true)]
+}
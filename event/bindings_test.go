@@ -0,0 +1,36 @@
+package event
+
+import "testing"
+
+func TestParseBinding(t *testing.T) {
+	tests := []struct {
+		raw     string
+		binding Binding
+		err     bool
+	}{
+		{raw: "KEY_F1", binding: Binding{Key: "KEY_F1"}},
+		{raw: "Ctrl+Shift+KEY_F1", binding: Binding{Ctrl: true, Shift: true, Key: "KEY_F1"}},
+		{raw: "alt+Z", binding: Binding{Alt: true, Key: "z"}},
+		{raw: "AltGr+e", binding: Binding{AltGr: true, Key: "e"}},
+		{raw: "Super+a", binding: Binding{Meta: true, Key: "a"}},
+		{raw: "Menu+KEY_UP", binding: Binding{Menu: true, Key: "KEY_UP"}},
+		{raw: "Ctrl++", binding: Binding{Ctrl: true, Key: "+"}},
+		{raw: "+", binding: Binding{Key: "+"}},
+		{raw: "", err: true},
+		{raw: "Ctrl+", err: true},
+		{raw: "Hyper+a", err: true},
+	}
+
+	for _, test := range tests {
+		binding, err := ParseBinding(test.raw)
+		if test.err {
+			if err == nil {
+				t.Errorf("%q: expected an error, got %+v", test.raw, binding)
+			}
+			continue
+		}
+		if err != nil || binding != test.binding {
+			t.Errorf("%q: got %+v, %v, expected %+v", test.raw, binding, err, test.binding)
+		}
+	}
+}
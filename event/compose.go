@@ -0,0 +1,219 @@
+package event
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// an optional Compose file in the save location extends the default table
+const ComposeFile = "Compose"
+
+const composeKey = "Multi_key"
+
+// dead keys are represented by their xkb keysym in compose sequences
+var deadKeysyms = map[string]string{
+	"`": "dead_grave",
+	"´": "dead_acute",
+	"^": "dead_circumflex",
+	"~": "dead_tilde",
+	"¨": "dead_diaeresis",
+	"¸": "dead_cedilla",
+}
+
+// each dead key lists the letters it combines with, followed by the result
+var deadKeyCombinations = map[string]string{
+	"dead_grave":      "aàeèiìoòuùAÀEÈIÌOÒUÙ",
+	"dead_acute":      "aáeéiíoóuúyýcćnńsśzźAÁEÉIÍOÓUÚYÝCĆNŃSŚZŹ",
+	"dead_circumflex": "aâeêiîoôuûAÂEÊIÎOÔUÛ",
+	"dead_tilde":      "aãnñoõAÃNÑOÕ",
+	"dead_diaeresis":  "aäeëiïoöuüyÿAÄEËIÏOÖUÜŸ",
+	"dead_cedilla":    "cçCÇ",
+}
+
+// after the compose key, these characters behave like the matching dead key
+var composeAccents = map[string]string{
+	"`":  "dead_grave",
+	"'":  "dead_acute",
+	"^":  "dead_circumflex",
+	"~":  "dead_tilde",
+	"\"": "dead_diaeresis",
+	",":  "dead_cedilla",
+}
+
+var composeExtras = map[string]string{
+	"ae": "æ",
+	"AE": "Æ",
+	"oe": "œ",
+	"OE": "Œ",
+	"ss": "ß",
+	"<<": "«",
+	">>": "»",
+	"!!": "¡",
+	"??": "¿",
+	"oc": "©",
+	"or": "®",
+	"=e": "€",
+	"-l": "£",
+	"oo": "°",
+	"..": "…",
+	"--": "—",
+}
+
+type composeEntry struct {
+	sequence []string
+	result   string
+}
+
+type composeTable struct {
+	sequences map[string]string
+	prefixes  map[string]bool
+}
+
+func (t *composeTable) add(sequence []string, result string) {
+	t.sequences[strings.Join(sequence, " ")] = result
+	for i := 1; i < len(sequence); i++ {
+		t.prefixes[strings.Join(sequence[:i], " ")] = true
+	}
+}
+
+func defaultComposeTable() *composeTable {
+	t := &composeTable{
+		sequences: map[string]string{},
+		prefixes:  map[string]bool{},
+	}
+
+	for deadKeysym, combinations := range deadKeyCombinations {
+		runes := []rune(combinations)
+		for i := 0; i+1 < len(runes); i += 2 {
+			t.add([]string{deadKeysym, string(runes[i])}, string(runes[i+1]))
+		}
+	}
+	for char, deadKeysym := range deadKeysyms {
+		// a dead key followed by space or itself produces the plain character
+		t.add([]string{deadKeysym, " "}, char)
+		t.add([]string{deadKeysym, deadKeysym}, char)
+	}
+
+	for accent, deadKeysym := range composeAccents {
+		runes := []rune(deadKeyCombinations[deadKeysym])
+		for i := 0; i+1 < len(runes); i += 2 {
+			t.add([]string{composeKey, accent, string(runes[i])}, string(runes[i+1]))
+			// the accent may be a dead key of the layout
+			if accentKeysym, ok := deadKeysyms[accent]; ok {
+				t.add([]string{composeKey, accentKeysym, string(runes[i])}, string(runes[i+1]))
+			}
+		}
+	}
+	for sequence, result := range composeExtras {
+		runes := []rune(sequence)
+		t.add([]string{composeKey, string(runes[0]), string(runes[1])}, result)
+	}
+
+	return t
+}
+
+var composeLock sync.RWMutex
+var compose = defaultComposeTable()
+
+var composeLineRegexp = regexp.MustCompile(`^((?:<\w+>\s*)+):\s*"((?:[^"\\]|\\.)*)"`)
+var composeTokenRegexp = regexp.MustCompile(`<(\w+)>`)
+
+// LoadCompose adds the sequences of the Compose file of the save location to
+// the default table. Only "<keysym> ... : "result"" lines are understood.
+func LoadCompose(saveLocation string) error {
+	content, err := os.ReadFile(path.Join(saveLocation, ComposeFile))
+	if err != nil {
+		return nil
+	}
+
+	loaded := []composeEntry{}
+
+	for i, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "include") {
+			continue
+		}
+
+		match := composeLineRegexp.FindStringSubmatch(line)
+		if match == nil {
+			return fmt.Errorf("%s line %d: malformed sequence", ComposeFile, i+1)
+		}
+
+		sequence := []string{}
+		for _, token := range composeTokenRegexp.FindAllStringSubmatch(match[1], -1) {
+			keysym := token[1]
+			if keysym != composeKey && !strings.HasPrefix(keysym, "dead_") {
+				char, err := keysymToChar(keysym)
+				if err != nil {
+					return fmt.Errorf("%s line %d: %v", ComposeFile, i+1, err)
+				}
+				keysym = char
+			}
+			sequence = append(sequence, keysym)
+		}
+		if len(sequence) < 2 {
+			return fmt.Errorf("%s line %d: a sequence needs at least two keys", ComposeFile, i+1)
+		}
+
+		result := strings.NewReplacer(`\"`, `"`, `\\`, `\`).Replace(match[2])
+		loaded = append(loaded, composeEntry{sequence, result})
+	}
+
+	composeLock.Lock()
+	defer composeLock.Unlock()
+	for _, entry := range loaded {
+		compose.add(entry.sequence, entry.result)
+	}
+
+	return nil
+}
+
+// composer accumulates the keys of a pending dead key or compose sequence
+type composer struct {
+	sequence []string
+}
+
+func (c *composer) isComposing() bool {
+	return len(c.sequence) > 0
+}
+
+func (c *composer) cancel() {
+	c.sequence = nil
+}
+
+// feed adds a token to the sequence. It returns the produced text once the
+// sequence is complete, or when it cannot match anything anymore: in that case
+// the typed characters are given back so nothing is lost.
+func (c *composer) feed(token string) (result string, done bool) {
+	c.sequence = append(c.sequence, token)
+	joined := strings.Join(c.sequence, " ")
+
+	composeLock.RLock()
+	defer composeLock.RUnlock()
+
+	if result, ok := compose.sequences[joined]; ok {
+		c.sequence = nil
+		return result, true
+	}
+	if compose.prefixes[joined] {
+		return "", false
+	}
+
+	for _, token := range c.sequence {
+		if token == composeKey {
+			continue
+		}
+		for char, deadKeysym := range deadKeysyms {
+			if token == deadKeysym {
+				token = char
+			}
+		}
+		result += token
+	}
+	c.sequence = nil
+	return result, true
+}
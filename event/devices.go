@@ -0,0 +1,156 @@
+package event
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MarinX/keylogger"
+	"github.com/asaskevich/EventBus"
+)
+
+// input devices are recognised by their name, like keylogger does, with
+// keypads and pedals added as they often come next to the main keyboard
+var allowedDevices = []string{"keyboard", "keypad", "pedal", "logitech mx keys"}
+var restrictedDevices = []string{"mouse"}
+
+// udev needs a moment to set up a new device node
+const plugDelay = 500 * time.Millisecond
+
+// InputManager merges the events of every connected keyboard into the KEY
+// stream, and follows keyboards being plugged and unplugged. The number of
+// connected keyboards is published on KEYBOARDS when it changes.
+type InputManager struct {
+	bus     EventBus.Bus
+	lock    sync.Mutex
+	devices map[string]*keylogger.KeyLogger
+	events  chan keylogger.InputEvent
+	leds    *Leds
+}
+
+func NewInputManager(bus EventBus.Bus) *InputManager {
+	return &InputManager{
+		bus:     bus,
+		devices: map[string]*keylogger.KeyLogger{},
+		events:  make(chan keylogger.InputEvent),
+		leds:    NewLeds(),
+	}
+}
+
+func (m *InputManager) Start() {
+	go BindKeyEvent(m.events, m.leds, m.bus)
+	m.scan()
+	go m.watch()
+}
+
+func (m *InputManager) Count() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return len(m.devices)
+}
+
+// watch rescans the keyboards when /dev/input changes, or periodically if
+// it cannot be watched
+func (m *InputManager) watch() {
+	err := watchDirectory("/dev/input", func() {
+		time.Sleep(plugDelay)
+		m.scan()
+	})
+
+	if err != nil {
+		println("inotify unavailable, polling input devices")
+		for {
+			time.Sleep(2 * time.Second)
+			m.scan()
+		}
+	}
+}
+
+func (m *InputManager) scan() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	added := false
+	for _, devicePath := range findKeyboardDevices() {
+		if _, ok := m.devices[devicePath]; ok {
+			continue
+		}
+
+		k, err := keylogger.New(devicePath)
+		if err != nil {
+			continue
+		}
+		println("Found a keyboard at", devicePath)
+
+		m.devices[devicePath] = k
+		m.leds.Add(devicePath)
+		go m.forward(devicePath, k)
+		added = true
+	}
+
+	if added {
+		m.bus.Publish("KEYBOARDS", len(m.devices))
+	}
+}
+
+// forward copies the events of a keyboard until it is unplugged
+func (m *InputManager) forward(devicePath string, k *keylogger.KeyLogger) {
+	held := map[uint16]bool{}
+	for e := range k.Read() {
+		if e.Type == keylogger.EvKey {
+			if e.KeyPress() {
+				held[e.Code] = true
+			} else if e.KeyRelease() {
+				delete(held, e.Code)
+			}
+		}
+		m.events <- e
+	}
+
+	// the keys held when unplugged are released, for them not to go on
+	// repeating or to leave their modifiers on
+	for code := range held {
+		m.events <- keylogger.InputEvent{Type: keylogger.EvKey, Code: code, Value: 0}
+	}
+
+	println("Lost keyboard at", devicePath)
+	k.Close()
+	m.leds.Remove(devicePath)
+
+	m.lock.Lock()
+	delete(m.devices, devicePath)
+	count := len(m.devices)
+	m.lock.Unlock()
+
+	m.bus.Publish("KEYBOARDS", count)
+}
+
+func findKeyboardDevices() (devicePaths []string) {
+	names, _ := filepath.Glob("/sys/class/input/event*/device/name")
+	for _, namePath := range names {
+		content, err := os.ReadFile(namePath)
+		if err != nil {
+			continue
+		}
+
+		name := strings.ToLower(string(content))
+		if matchesDevice(name, restrictedDevices) || !matchesDevice(name, allowedDevices) {
+			continue
+		}
+
+		eventName := filepath.Base(filepath.Dir(filepath.Dir(namePath)))
+		devicePaths = append(devicePaths, filepath.Join("/dev/input", eventName))
+	}
+	return
+}
+
+func matchesDevice(name string, devices []string) bool {
+	for _, device := range devices {
+		if strings.Contains(name, device) {
+			return true
+		}
+	}
+	return false
+}
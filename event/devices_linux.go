@@ -0,0 +1,25 @@
+package event
+
+import "syscall"
+
+// watchDirectory calls changed each time a file of dir is created, deleted or
+// changes attributes, until the watch is lost
+func watchDirectory(dir string, changed func()) error {
+	fd, err := syscall.InotifyInit()
+	if err == nil {
+		_, err = syscall.InotifyAddWatch(fd, dir, syscall.IN_CREATE|syscall.IN_DELETE|syscall.IN_ATTRIB)
+	}
+	if err != nil {
+		return err
+	}
+
+	buffer := make([]byte, 4096)
+	for {
+		if _, err := syscall.Read(fd, buffer); err != nil {
+			println("lost inotify watch on", dir)
+			syscall.Close(fd)
+			return nil
+		}
+		changed()
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+// +build !linux
+
+package event
+
+import "errors"
+
+// without inotify the input devices are polled
+func watchDirectory(dir string, changed func()) error {
+	return errors.New("inotify is only available on linux")
+}
@@ -83,15 +83,25 @@ var KeyCode = map[int]string{
 	87: "KEY_F11",
 	88: "KEY_F12",
 
+	97: "KEY_R_CTRL",
+
 	100: "KEY_ALT_GR",
 
+	102: "KEY_HOME",
 	103: "KEY_UP",
+	104: "KEY_PAGEUP",
 	105: "KEY_LEFT",
 	106: "KEY_RIGHT",
+	107: "KEY_END",
 	108: "KEY_DOWN",
+	109: "KEY_PAGEDOWN",
 
 	111: "KEY_DEL",
 
+	125: "KEY_L_META",
+	126: "KEY_R_META",
+	127: "KEY_COMPOSE",
+
 	183: "KEY_F13",
 	184: "KEY_F14",
 	185: "KEY_F15",
@@ -209,3 +219,49 @@ var KeyCodeAltGr = map[int]string{
 	51: "─",
 	52: "·",
 }
+
+var KeyCodeShiftAltGr = map[int]string{
+	3:  "⅛",
+	4:  "£",
+	5:  "$",
+	6:  "⅜",
+	7:  "⅝",
+	8:  "⅞",
+	9:  "™",
+	10: "±",
+	11: "°",
+	12: "¿",
+
+	16: "Æ",
+	17: "<",
+	18: "¢",
+	19: "®",
+	20: "Ŧ",
+	21: "¥",
+	22: "↑",
+	23: "ı",
+	24: "Ø",
+	25: "Þ",
+
+	30: "Ω",
+	31: "§",
+	32: "Ð",
+	33: "ª",
+	34: "Ŋ",
+	35: "Ħ",
+
+	37: "&",
+	38: "Ł",
+	39: "º",
+
+	43: "¦",
+	44: "Ł",
+	45: ">",
+	46: "©",
+	47: "‘",
+	48: "’",
+	49: "N",
+
+	51: "×",
+	52: "÷",
+}
@@ -7,31 +7,47 @@ import (
 )
 
 type KeyEvent struct {
-	IsCtrl      bool
-	IsAlt       bool
-	IsAltGr     bool
-	IsShift     bool
-	IsShiftLock bool
-	KeyCode     int
-	IsChar      bool
-	KeyChar     string
-	KeyValue    string
+	Modifiers Modifier
+	KeyCode   int
+	IsChar    bool
+	KeyChar   string
+	KeyValue  string
+	IsRepeat  bool
 }
 
-func BindKeyEvent(k *keylogger.KeyLogger, b EventBus.Bus) {
-	event := KeyEvent{
-		IsShift:     false,
-		IsShiftLock: false,
-		IsAltGr:     false,
-		IsAlt:       false,
-		IsCtrl:      false,
-	}
+// BindKeyEvent publishes the events of the keyboards until events is closed
+func BindKeyEvent(events <-chan keylogger.InputEvent, leds *Leds, b EventBus.Bus) {
+	event := KeyEvent{}
+	leds.SetCapsLock(false)
+
+	keys := composer{}
+
+	repeats := newRepeater()
+	defer repeats.stop()
+
+read:
+	for {
+		var e keylogger.InputEvent
+		select {
+		case input, ok := <-events:
+			if !ok {
+				break read
+			}
+			// kernel repeats are replaced by ours when configured
+			if input.Value == 2 && repeats.isSynthesized() {
+				continue
+			}
+			e = input
+		case e = <-repeats.events:
+		}
 
-	events := k.Read()
-	for e := range events {
 		if e.Type == keylogger.EvKey {
 
+			layout := CurrentLayout()
 			keyValue := KeyCode[int(e.Code)]
+			if char, ok := layout.Base[int(e.Code)]; ok {
+				keyValue = char
+			}
 			if keyValue == "" {
 				continue
 			}
@@ -40,45 +56,54 @@ func BindKeyEvent(k *keylogger.KeyLogger, b EventBus.Bus) {
 			event.IsChar = false
 			event.KeyCode = int(e.Code)
 			event.KeyValue = keyValue
+			event.IsRepeat = e.Value == 2
 
-			if e.KeyPress() {
-				switch keyValue {
-				case "KEY_L_SHIFT", "KEY_R_SHIFT":
-					event.IsShift = true
-				case "KEY_CAPSLOCK":
-					event.IsShiftLock = !event.IsShiftLock
-				case "KEY_ALT_GR":
-					event.IsAltGr = true
-				case "KEY_L_ALT":
-					event.IsAlt = true
-				case "KEY_L_CTRL", "KEY_R_CTRL":
-					event.IsCtrl = true
+			// held modifiers and compose keys do not repeat
+			if event.IsRepeat && (isModifier(keyValue) || keyValue == "KEY_COMPOSE") {
+				continue
+			}
 
+			if e.KeyPress() {
+				if !isModifier(keyValue) {
+					repeats.start(e.Code)
+				}
+				if keyValue == "KEY_CAPSLOCK" {
+					event.Modifiers ^= CapsLock
+					leds.SetCapsLock(event.IsShiftLock())
 				}
+				event.Modifiers |= modifierKeys[keyValue]
 			}
 
 			if e.KeyRelease() {
-				switch keyValue {
-				case "KEY_L_SHIFT", "KEY_R_SHIFT":
-					event.IsShift = false
-				case "KEY_ALT_GR":
-					event.IsAltGr = false
-				case "KEY_L_GR":
-					event.IsAlt = false
-				case "KEY_L_CTRL", "KEY_R_CTRL":
-					event.IsCtrl = false
-				}
+				repeats.release(e.Code)
+				event.Modifiers &^= modifierKeys[keyValue]
 			} else {
 
 				// letters
 				if utils.IsLetter(keyValue) {
 					event.IsChar = true
-					if event.IsShift || event.IsShiftLock {
-						event.KeyChar = KeyCodeMaj[int(e.Code)]
-					} else if event.IsAltGr {
-						event.KeyChar = KeyCodeAltGr[int(e.Code)]
-					} else {
-						event.KeyChar = KeyCode[int(e.Code)]
+					event.KeyChar = layout.Resolve(int(e.Code), event.IsShift(), event.IsShiftLock(), event.IsAltGr())
+				}
+
+				if event.IsRepeat && event.IsChar && layout.DeadKeys[event.KeyChar] {
+					continue
+				}
+
+				// dead keys and compose sequences
+				if keyValue == "KEY_COMPOSE" {
+					keys.cancel()
+					keys.feed(composeKey)
+					continue
+				}
+
+				if keys.isComposing() || (event.IsChar && layout.DeadKeys[event.KeyChar]) {
+					if token, ok := composeToken(layout, event); ok {
+						if result, done := keys.feed(token); done {
+							publishText(b, event, result)
+						}
+						continue
+					} else if !isModifier(keyValue) {
+						keys.cancel()
 					}
 				}
 
@@ -87,6 +112,27 @@ func BindKeyEvent(k *keylogger.KeyLogger, b EventBus.Bus) {
 
 		}
 	}
-	println("lost keyboadr")
-	b.Publish("REQUIRE_KEYBOARD")
+}
+
+func composeToken(layout *Layout, event KeyEvent) (string, bool) {
+	if event.IsChar && event.KeyChar != "" {
+		if layout.DeadKeys[event.KeyChar] {
+			return deadKeysyms[event.KeyChar], true
+		}
+		return event.KeyChar, true
+	}
+	if event.KeyValue == "KEY_SPACE" {
+		return " ", true
+	}
+	return "", false
+}
+
+// publish composed text as if each character had been typed
+func publishText(b EventBus.Bus, event KeyEvent, text string) {
+	for _, char := range text {
+		event.IsChar = true
+		event.KeyChar = string(char)
+		event.KeyValue = string(char)
+		b.Publish("KEY", event)
+	}
 }
@@ -0,0 +1,424 @@
+package event
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// user keymaps live in this directory of the save location, one file per
+// layout, either as json or as a subset of the xkb symbols format
+const KeymapDirectory = "keymaps"
+
+// json keymaps map an evdev code to its levels: base, shift, altgr and shift+altgr,
+// the codes left out being taken from the bundled layout named by base
+type jsonKeymap struct {
+	Name     string              `json:"name"`
+	Label    string              `json:"label"`
+	Base     string              `json:"base"`
+	Keys     map[string][]string `json:"keys"`
+	DeadKeys []string            `json:"deadKeys"`
+}
+
+// LoadKeymaps reads every keymap file of the save location and registers the
+// valid ones next to the bundled layouts. Malformed files are reported, not loaded.
+func LoadKeymaps(saveLocation string) (errs []error) {
+	dir := path.Join(saveLocation, KeymapDirectory)
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	for _, file := range files {
+		var layout *Layout
+		var err error
+
+		filePath := path.Join(dir, file.Name())
+		switch path.Ext(file.Name()) {
+		case ".json":
+			layout, err = LoadJsonKeymap(filePath)
+		case ".xkb":
+			layout, err = LoadXkbKeymap(filePath)
+		default:
+			continue
+		}
+
+		if err == nil {
+			err = registerLayout(layout)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", file.Name(), err))
+		}
+	}
+
+	return errs
+}
+
+func registerLayout(layout *Layout) error {
+	for _, existing := range Layouts {
+		if existing.Name == layout.Name {
+			return fmt.Errorf("layout name %q is already used", layout.Name)
+		}
+	}
+	Layouts = append(Layouts, layout)
+	return nil
+}
+
+func newEmptyLayout(name string) *Layout {
+	return &Layout{
+		Name:       name,
+		Label:      name,
+		Base:       map[int]string{},
+		Shift:      map[int]string{},
+		AltGr:      map[int]string{},
+		ShiftAltGr: map[int]string{},
+		DeadKeys:   map[string]bool{},
+	}
+}
+
+func bundledLayout(name string) (*Layout, error) {
+	for _, layout := range bundledLayouts {
+		if layout.Name == name {
+			return layout, nil
+		}
+	}
+	return nil, fmt.Errorf("%q is not a bundled layout", name)
+}
+
+// inherit copies the keys of base the layout does not define, with its dead
+// keys and caps lock behaviour
+func (l *Layout) inherit(base *Layout) {
+	levels := []map[int]string{l.Base, l.Shift, l.AltGr, l.ShiftAltGr}
+	baseLevels := []map[int]string{base.Base, base.Shift, base.AltGr, base.ShiftAltGr}
+	defined := map[int]bool{}
+	for _, level := range levels {
+		for code := range level {
+			defined[code] = true
+		}
+	}
+
+	for i, level := range baseLevels {
+		for code, char := range level {
+			if !defined[code] && !strings.Contains(KeyCode[code], "KEY") {
+				levels[i][code] = char
+			}
+		}
+	}
+	for char := range base.DeadKeys {
+		l.DeadKeys[char] = true
+	}
+	l.ShiftLock = base.ShiftLock
+}
+
+// the letter keys, which every layout must give a character
+var letterBlock = [][2]int{{16, 25}, {30, 38}, {44, 50}}
+
+func (l *Layout) checkLetters() error {
+	missing := []string{}
+	for _, block := range letterBlock {
+		for code := block[0]; code <= block[1]; code++ {
+			if l.Base[code] == "" {
+				missing = append(missing, strconv.Itoa(code))
+			}
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("letter keys %s have no character", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func (l *Layout) setLevels(code int, levels []string) error {
+	if code <= 0 || code > 255 {
+		return fmt.Errorf("key code %d is out of range", code)
+	}
+	if name := KeyCode[code]; strings.Contains(name, "KEY") {
+		return fmt.Errorf("key code %d is %s and cannot produce characters", code, name)
+	}
+	if len(levels) == 0 || len(levels) > 4 {
+		return fmt.Errorf("key code %d must have between 1 and 4 levels, got %d", code, len(levels))
+	}
+
+	targets := []map[int]string{l.Base, l.Shift, l.AltGr, l.ShiftAltGr}
+	for i, char := range levels {
+		if char == "" {
+			continue
+		}
+		if utf8.RuneCountInString(char) != 1 {
+			return fmt.Errorf("key code %d, level %d: %q is not a single character", code, i+1, char)
+		}
+		targets[i][code] = char
+	}
+
+	return nil
+}
+
+func LoadJsonKeymap(filePath string) (*Layout, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var keymap jsonKeymap
+	if err := json.Unmarshal(content, &keymap); err != nil {
+		return nil, fmt.Errorf("invalid json: %v", err)
+	}
+
+	name := keymap.Name
+	if name == "" {
+		name = strings.TrimSuffix(path.Base(filePath), path.Ext(filePath))
+	}
+	layout := newEmptyLayout(name)
+	if keymap.Label != "" {
+		layout.Label = keymap.Label
+	}
+
+	if len(keymap.Keys) == 0 {
+		return nil, fmt.Errorf("no keys defined")
+	}
+
+	for rawCode, levels := range keymap.Keys {
+		code, err := strconv.Atoi(rawCode)
+		if err != nil {
+			return nil, fmt.Errorf("key %q is not an evdev code", rawCode)
+		}
+		if err := layout.setLevels(code, levels); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, char := range keymap.DeadKeys {
+		if _, ok := deadKeysyms[char]; !ok {
+			return nil, fmt.Errorf("%q cannot be a dead key", char)
+		}
+		layout.DeadKeys[char] = true
+	}
+
+	if keymap.Base != "" {
+		base, err := bundledLayout(keymap.Base)
+		if err != nil {
+			return nil, fmt.Errorf("base: %v", err)
+		}
+		layout.inherit(base)
+	}
+
+	if err := layout.checkLetters(); err != nil {
+		return nil, fmt.Errorf("%v, define them or set a base layout", err)
+	}
+
+	return layout, nil
+}
+
+var xkbNameRegexp = regexp.MustCompile(`^name\[\w+\]\s*=\s*"([^"]*)"\s*;`)
+var xkbKeyRegexp = regexp.MustCompile(`^key\s*<(\w+)>\s*\{\s*\[([^\]]*)\]\s*\}\s*;`)
+var xkbIncludeRegexp = regexp.MustCompile(`^include\s*"([^"]*)"`)
+
+// the xkb symbols of the bundled layouts, which keymaps can include
+var xkbIncludes = map[string]string{
+	"fr":          "azerty",
+	"us":          "qwerty-us",
+	"gb":          "qwerty-uk",
+	"de":          "qwertz-de",
+	"us(dvorak)":  "dvorak",
+	"us(colemak)": "colemak",
+}
+
+// LoadXkbKeymap only understands name, key and include statements, e.g.
+//
+//	include "us"
+//	key <AD01> { [ q, Q, at, U03A9 ] };
+//
+// where only the bundled layouts can be included, other statements
+// (modifier_map, ...) and includes being ignored
+func LoadXkbKeymap(filePath string) (*Layout, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	layout := newEmptyLayout(strings.TrimSuffix(path.Base(filePath), path.Ext(filePath)))
+	keyCount := 0
+	var base *Layout
+
+	for i, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(strings.SplitN(line, "//", 2)[0])
+
+		if match := xkbNameRegexp.FindStringSubmatch(line); match != nil {
+			layout.Label = match[1]
+			continue
+		}
+
+		if match := xkbIncludeRegexp.FindStringSubmatch(line); match != nil {
+			name := strings.TrimSuffix(match[1], "(basic)")
+			if bundled, ok := xkbIncludes[name]; ok {
+				name = bundled
+			}
+			if bundled, err := bundledLayout(name); err == nil {
+				base = bundled
+			}
+			continue
+		}
+
+		if !strings.HasPrefix(line, "key ") && !strings.HasPrefix(line, "key<") {
+			continue
+		}
+
+		match := xkbKeyRegexp.FindStringSubmatch(line)
+		if match == nil {
+			return nil, fmt.Errorf("line %d: malformed key statement", i+1)
+		}
+
+		code, ok := xkbKeyNames[match[1]]
+		if !ok {
+			return nil, fmt.Errorf("line %d: unknown key <%s>", i+1, match[1])
+		}
+
+		levels := []string{}
+		for _, keysym := range strings.Split(match[2], ",") {
+			char, err := keysymToChar(strings.TrimSpace(keysym))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", i+1, err)
+			}
+			if strings.HasPrefix(strings.TrimSpace(keysym), "dead_") {
+				layout.DeadKeys[char] = true
+			}
+			levels = append(levels, char)
+		}
+
+		if err := layout.setLevels(code, levels); err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+1, err)
+		}
+		keyCount++
+	}
+
+	if keyCount == 0 && base == nil {
+		return nil, fmt.Errorf("no keys defined")
+	}
+
+	if base != nil {
+		layout.inherit(base)
+	}
+
+	if err := layout.checkLetters(); err != nil {
+		return nil, fmt.Errorf("%v, define them or include a bundled layout", err)
+	}
+
+	return layout, nil
+}
+
+func keysymToChar(keysym string) (string, error) {
+	if keysym == "" {
+		return "", fmt.Errorf("empty keysym")
+	}
+	if utf8.RuneCountInString(keysym) == 1 {
+		return keysym, nil
+	}
+	if char, ok := keysymNames[keysym]; ok {
+		return char, nil
+	}
+	// unicode keysyms, like U00E9
+	if strings.HasPrefix(keysym, "U") {
+		if code, err := strconv.ParseInt(keysym[1:], 16, 32); err == nil {
+			return string(rune(code)), nil
+		}
+	}
+	return "", fmt.Errorf("unknown keysym %q", keysym)
+}
+
+// xkb key names of the alphanumeric block and their evdev codes
+var xkbKeyNames = func() map[string]int {
+	names := map[string]int{
+		"TLDE": 41,
+		"BKSL": 43,
+		"LSGT": 86,
+	}
+	for i := 0; i < 12; i++ {
+		names[fmt.Sprintf("AE%02d", i+1)] = 2 + i
+		names[fmt.Sprintf("AD%02d", i+1)] = 16 + i
+		names[fmt.Sprintf("AC%02d", i+1)] = 30 + i
+	}
+	// AC12 is the backslash key on iso keyboards
+	names["AC12"] = 43
+	for i := 0; i < 10; i++ {
+		names[fmt.Sprintf("AB%02d", i+1)] = 44 + i
+	}
+	return names
+}()
+
+// latin-1 keysyms, in code point order from U+00A0
+var latin1Keysyms = []string{
+	"nobreakspace", "exclamdown", "cent", "sterling", "currency", "yen", "brokenbar", "section",
+	"diaeresis", "copyright", "ordfeminine", "guillemotleft", "notsign", "hyphen", "registered", "macron",
+	"degree", "plusminus", "twosuperior", "threesuperior", "acute", "mu", "paragraph", "periodcentered",
+	"cedilla", "onesuperior", "masculine", "guillemotright", "onequarter", "onehalf", "threequarters", "questiondown",
+	"Agrave", "Aacute", "Acircumflex", "Atilde", "Adiaeresis", "Aring", "AE", "Ccedilla",
+	"Egrave", "Eacute", "Ecircumflex", "Ediaeresis", "Igrave", "Iacute", "Icircumflex", "Idiaeresis",
+	"ETH", "Ntilde", "Ograve", "Oacute", "Ocircumflex", "Otilde", "Odiaeresis", "multiply",
+	"Oslash", "Ugrave", "Uacute", "Ucircumflex", "Udiaeresis", "Yacute", "THORN", "ssharp",
+	"agrave", "aacute", "acircumflex", "atilde", "adiaeresis", "aring", "ae", "ccedilla",
+	"egrave", "eacute", "ecircumflex", "ediaeresis", "igrave", "iacute", "icircumflex", "idiaeresis",
+	"eth", "ntilde", "ograve", "oacute", "ocircumflex", "otilde", "odiaeresis", "division",
+	"oslash", "ugrave", "uacute", "ucircumflex", "udiaeresis", "yacute", "thorn", "ydiaeresis",
+}
+
+var keysymNames = func() map[string]string {
+	names := map[string]string{
+		"NoSymbol":        "",
+		"VoidSymbol":      "",
+		"space":           " ",
+		"exclam":          "!",
+		"quotedbl":        "\"",
+		"numbersign":      "#",
+		"dollar":          "$",
+		"percent":         "%",
+		"ampersand":       "&",
+		"apostrophe":      "'",
+		"parenleft":       "(",
+		"parenright":      ")",
+		"asterisk":        "*",
+		"plus":            "+",
+		"comma":           ",",
+		"minus":           "-",
+		"period":          ".",
+		"slash":           "/",
+		"colon":           ":",
+		"semicolon":       ";",
+		"less":            "<",
+		"equal":           "=",
+		"greater":         ">",
+		"question":        "?",
+		"at":              "@",
+		"bracketleft":     "[",
+		"backslash":       "\\",
+		"bracketright":    "]",
+		"asciicircum":     "^",
+		"underscore":      "_",
+		"grave":           "`",
+		"braceleft":       "{",
+		"bar":             "|",
+		"braceright":      "}",
+		"asciitilde":      "~",
+		"EuroSign":        "€",
+		"oe":              "œ",
+		"OE":              "Œ",
+		"guillemetleft":   "«",
+		"guillemetright":  "»",
+		"dead_grave":      "`",
+		"dead_acute":      "´",
+		"dead_circumflex": "^",
+		"dead_tilde":      "~",
+		"dead_diaeresis":  "¨",
+		"dead_cedilla":    "¸",
+	}
+	for i, name := range latin1Keysyms {
+		names[name] = string(rune(0xA0 + i))
+	}
+	names["Ooblique"] = names["Oslash"]
+	names["ooblique"] = names["oslash"]
+	return names
+}()
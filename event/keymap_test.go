@@ -0,0 +1,78 @@
+package event
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeKeymap(t *testing.T, name string, content string) string {
+	keymapPath := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(keymapPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return keymapPath
+}
+
+func TestLoadJsonKeymap(t *testing.T) {
+	tests := []struct {
+		content string
+		err     string
+	}{
+		{`{"base": "qwerty-us", "keys": {"39": ["ç", "Ç"]}}`, ""},
+		{`{"keys": `, "invalid json"},
+		{`{"base": "qwerty-us"}`, "no keys defined"},
+		{`{"base": "qwerty-us", "keys": {"a": ["q"]}}`, `key "a" is not an evdev code`},
+		{`{"base": "qwerty-us", "keys": {"300": ["q"]}}`, "out of range"},
+		{`{"base": "qwerty-us", "keys": {"28": ["q"]}}`, "cannot produce characters"},
+		{`{"base": "qwerty-us", "keys": {"16": []}}`, "between 1 and 4 levels"},
+		{`{"base": "qwerty-us", "keys": {"16": ["q", "Q", "@", "Ω", "x"]}}`, "between 1 and 4 levels"},
+		{`{"base": "qwerty-us", "keys": {"16": ["qq"]}}`, "not a single character"},
+		{`{"base": "qwerty-us", "keys": {"16": ["q"]}, "deadKeys": ["q"]}`, "cannot be a dead key"},
+		{`{"base": "portuguese", "keys": {"16": ["q"]}}`, "not a bundled layout"},
+		{`{"keys": {"16": ["q"], "39": ["ç"]}}`, "letter keys 17, 18"},
+	}
+
+	for _, test := range tests {
+		_, err := LoadJsonKeymap(writeKeymap(t, "pt.json", test.content))
+		checkError(t, test.content, err, test.err)
+	}
+
+	if _, err := LoadJsonKeymap(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("missing file: expected an error")
+	}
+}
+
+func TestLoadXkbKeymap(t *testing.T) {
+	tests := []struct {
+		content string
+		err     string
+	}{
+		{"include \"us\"\nkey <AC10> { [ ccedilla, Ccedilla ] };", ""},
+		{"include \"fr(basic)\"", ""},
+		{"name[Group1] = \"Portuguese\";", "no keys defined"},
+		{"include \"us\"\nkey <AC10> { [ ccedilla, Ccedilla ]", "line 2: malformed key statement"},
+		{"include \"us\"\nkey <FK01> { [ q ] };", "line 2: unknown key <FK01>"},
+		{"include \"us\"\nkey <AC10> { [ ccedilla, nokeysym ] };", `line 2: unknown keysym "nokeysym"`},
+		{"include \"us\"\nkey <AC10> { [ ccedilla, , ] };", "line 2: empty keysym"},
+		{"include \"us\"\nkey <AC10> { [ a, b, c, d, e ] };", "line 2: key code 39 must have between 1 and 4 levels"},
+		{"include \"level3(ralt_switch)\"\nkey <AC10> { [ ccedilla ] };", "include a bundled layout"},
+	}
+
+	for _, test := range tests {
+		_, err := LoadXkbKeymap(writeKeymap(t, "pt.xkb", test.content))
+		checkError(t, test.content, err, test.err)
+	}
+}
+
+func checkError(t *testing.T, content string, err error, expected string) {
+	t.Helper()
+	if expected == "" {
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", content, err)
+		}
+	} else if err == nil || !strings.Contains(err.Error(), expected) {
+		t.Errorf("%q: got error %v, expected %q", content, err, expected)
+	}
+}
@@ -0,0 +1,122 @@
+package event
+
+import (
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+type Layout struct {
+	Name       string
+	Label      string
+	Base       map[int]string
+	Shift      map[int]string
+	AltGr      map[int]string
+	ShiftAltGr map[int]string
+	// characters that combine with the next key instead of being typed
+	DeadKeys map[string]bool
+	// caps lock shifts every key, as on french typewriters, instead of only
+	// the letters
+	ShiftLock bool
+}
+
+var Azerty = &Layout{
+	Name:       "azerty",
+	Label:      "AZERTY (FR)",
+	Base:       KeyCode,
+	Shift:      KeyCodeMaj,
+	AltGr:      KeyCodeAltGr,
+	ShiftAltGr: KeyCodeShiftAltGr,
+	DeadKeys: map[string]bool{
+		"^": true,
+		"¨": true,
+	},
+	ShiftLock: true,
+}
+
+var bundledLayouts = []*Layout{
+	Azerty,
+	QwertyUS,
+	QwertyUK,
+	QwertzDE,
+	Dvorak,
+	Colemak,
+}
+
+// the bundled layouts, followed by the keymaps of the user
+var Layouts = append([]*Layout{}, bundledLayouts...)
+
+var layoutLock sync.RWMutex
+var currentLayout = Azerty
+
+// fall back on azerty when name is unknown, as it used to be the only layout
+func FindLayout(name string) *Layout {
+	for _, layout := range Layouts {
+		if layout.Name == name {
+			return layout
+		}
+	}
+	return Azerty
+}
+
+func SetLayout(name string) {
+	layoutLock.Lock()
+	defer layoutLock.Unlock()
+	currentLayout = FindLayout(name)
+}
+
+func CurrentLayout() *Layout {
+	layoutLock.RLock()
+	defer layoutLock.RUnlock()
+	return currentLayout
+}
+
+// NextLayout returns the layout following name, wrapping around
+func NextLayout(name string) *Layout {
+	for i, layout := range Layouts {
+		if layout.Name == name {
+			return Layouts[(i+1)%len(Layouts)]
+		}
+	}
+	return Layouts[0]
+}
+
+// Resolve returns the character produced by a key code for the given modifiers
+func (l *Layout) Resolve(code int, shift bool, capsLock bool, altGr bool) string {
+	plain, shifted := l.Base[code], l.Shift[code]
+	if altGr {
+		plain, shifted = l.AltGr[code], l.ShiftAltGr[code]
+		// keys without a Shift+AltGr character give their AltGr one
+		if shifted == "" {
+			shifted = plain
+		}
+	}
+
+	// caps lock only shifts the letters having an upper case, Shift giving
+	// back the lower case, and leaves the AltGr symbols of shift lock layouts
+	if capsLock && ((l.ShiftLock && !altGr) || isCased(plain)) {
+		shift = !shift
+	}
+
+	if shift {
+		return shifted
+	}
+	return plain
+}
+
+func isCased(char string) bool {
+	r, _ := utf8.DecodeRuneInString(char)
+	return unicode.IsLower(r) && unicode.ToUpper(r) != r
+}
+
+// keys on a keyboard row have consecutive codes, so a row is described
+// by its first code and the characters it produces
+func rows(r map[int]string) map[int]string {
+	level := map[int]string{}
+	for start, chars := range r {
+		for i, char := range []rune(chars) {
+			level[start+i] = string(char)
+		}
+	}
+	return level
+}
@@ -0,0 +1,129 @@
+package event
+
+var QwertyUS = &Layout{
+	Name:  "qwerty-us",
+	Label: "QWERTY (US)",
+	Base: rows(map[int]string{
+		2:  "1234567890-=",
+		16: "qwertyuiop[]",
+		30: "asdfghjkl;'`",
+		43: "\\zxcvbnm,./",
+	}),
+	Shift: rows(map[int]string{
+		2:  "!@#$%^&*()_+",
+		16: "QWERTYUIOP{}",
+		30: "ASDFGHJKL:\"~",
+		43: "|ZXCVBNM<>?",
+	}),
+	AltGr: map[int]string{},
+}
+
+var QwertyUK = &Layout{
+	Name:  "qwerty-uk",
+	Label: "QWERTY (UK)",
+	Base: rows(map[int]string{
+		2:  "1234567890-=",
+		16: "qwertyuiop[]",
+		30: "asdfghjkl;'`",
+		43: "#zxcvbnm,./",
+		86: "\\",
+	}),
+	Shift: rows(map[int]string{
+		2:  "!\"£$%^&*()_+",
+		16: "QWERTYUIOP{}",
+		30: "ASDFGHJKL:@¬",
+		43: "~ZXCVBNM<>?",
+		86: "|",
+	}),
+	AltGr: rows(map[int]string{
+		5:  "€",
+		18: "é",
+		22: "úíó",
+		30: "á",
+		41: "¦",
+	}),
+	ShiftAltGr: rows(map[int]string{
+		5:  "½",
+		18: "É",
+		22: "ÚÍÓ",
+		30: "Á",
+	}),
+}
+
+var QwertzDE = &Layout{
+	Name:  "qwertz-de",
+	Label: "QWERTZ (DE)",
+	Base: rows(map[int]string{
+		2:  "1234567890ß´",
+		16: "qwertzuiopü+",
+		30: "asdfghjklöä^",
+		43: "#yxcvbnm,.-",
+		86: "<",
+	}),
+	Shift: rows(map[int]string{
+		2:  "!\"§$%&/()=?`",
+		16: "QWERTZUIOPÜ*",
+		30: "ASDFGHJKLÖÄ°",
+		43: "'YXCVBNM;:_",
+		86: ">",
+	}),
+	AltGr: rows(map[int]string{
+		3:  "²³",
+		8:  "{[]}\\",
+		16: "@",
+		18: "€",
+		27: "~",
+		50: "µ",
+		86: "|",
+	}),
+	ShiftAltGr: rows(map[int]string{
+		3:  "⅛£",
+		8:  "⅞™±°¿",
+		16: "Ω",
+		18: "¢",
+		27: "¯",
+		50: "º",
+		86: "¦",
+	}),
+	DeadKeys: map[string]bool{
+		"^": true,
+		"´": true,
+		"`": true,
+	},
+}
+
+var Dvorak = &Layout{
+	Name:  "dvorak",
+	Label: "Dvorak",
+	Base: rows(map[int]string{
+		2:  "1234567890[]",
+		16: "',.pyfgcrl/=",
+		30: "aoeuidhtns-`",
+		43: "\\;qjkxbmwvz",
+	}),
+	Shift: rows(map[int]string{
+		2:  "!@#$%^&*(){}",
+		16: "\"<>PYFGCRL?+",
+		30: "AOEUIDHTNS_~",
+		43: "|:QJKXBMWVZ",
+	}),
+	AltGr: map[int]string{},
+}
+
+var Colemak = &Layout{
+	Name:  "colemak",
+	Label: "Colemak",
+	Base: rows(map[int]string{
+		2:  "1234567890-=",
+		16: "qwfpgjluy;[]",
+		30: "arstdhneio'`",
+		43: "\\zxcvbkm,./",
+	}),
+	Shift: rows(map[int]string{
+		2:  "!@#$%^&*()_+",
+		16: "QWFPGJLUY:{}",
+		30: "ARSTDHNEIO\"~",
+		43: "|ZXCVBKM<>?",
+	}),
+	AltGr: map[int]string{},
+}
@@ -0,0 +1,129 @@
+package event
+
+import (
+	"encoding/binary"
+	"os"
+	"sync"
+
+	"github.com/MarinX/keylogger"
+)
+
+type Modifier uint16
+
+const (
+	LeftCtrl Modifier = 1 << iota
+	RightCtrl
+	LeftShift
+	RightShift
+	LeftAlt
+	// right alt is AltGr
+	RightAlt
+	LeftMeta
+	RightMeta
+	CapsLock
+)
+
+const (
+	Ctrl  = LeftCtrl | RightCtrl
+	Shift = LeftShift | RightShift
+	Meta  = LeftMeta | RightMeta
+)
+
+// keys held to apply a modifier, caps lock is toggled and handled apart
+var modifierKeys = map[string]Modifier{
+	"KEY_L_CTRL":  LeftCtrl,
+	"KEY_R_CTRL":  RightCtrl,
+	"KEY_L_SHIFT": LeftShift,
+	"KEY_R_SHIFT": RightShift,
+	"KEY_L_ALT":   LeftAlt,
+	"KEY_ALT_GR":  RightAlt,
+	"KEY_L_META":  LeftMeta,
+	"KEY_R_META":  RightMeta,
+}
+
+func isModifier(keyValue string) bool {
+	_, ok := modifierKeys[keyValue]
+	return ok || keyValue == "KEY_CAPSLOCK"
+}
+
+func (e KeyEvent) Has(modifier Modifier) bool {
+	return e.Modifiers&modifier != 0
+}
+
+func (e KeyEvent) IsCtrl() bool {
+	return e.Has(Ctrl)
+}
+
+func (e KeyEvent) IsShift() bool {
+	return e.Has(Shift)
+}
+
+func (e KeyEvent) IsAlt() bool {
+	return e.Has(LeftAlt)
+}
+
+func (e KeyEvent) IsAltGr() bool {
+	return e.Has(RightAlt)
+}
+
+func (e KeyEvent) IsMeta() bool {
+	return e.Has(Meta)
+}
+
+func (e KeyEvent) IsShiftLock() bool {
+	return e.Has(CapsLock)
+}
+
+const evLed = 0x11
+const ledCapsLock = 0x01
+
+// Leds drives the lock indicators of every connected keyboard
+type Leds struct {
+	lock     sync.Mutex
+	devices  map[string]*os.File
+	capsLock bool
+}
+
+func NewLeds() *Leds {
+	return &Leds{devices: map[string]*os.File{}}
+}
+
+// Add starts driving the leds of a keyboard, which get the present state
+func (l *Leds) Add(devicePath string) {
+	device, err := os.OpenFile(devicePath, os.O_WRONLY, os.ModeCharDevice)
+	if err != nil {
+		return
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.devices[devicePath] = device
+	writeLed(device, ledCapsLock, l.capsLock)
+}
+
+func (l *Leds) Remove(devicePath string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if device, ok := l.devices[devicePath]; ok {
+		device.Close()
+		delete(l.devices, devicePath)
+	}
+}
+
+func (l *Leds) SetCapsLock(on bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.capsLock = on
+	for _, device := range l.devices {
+		writeLed(device, ledCapsLock, on)
+	}
+}
+
+func writeLed(device *os.File, led uint16, on bool) {
+	value := int32(0)
+	if on {
+		value = 1
+	}
+	binary.Write(device, binary.LittleEndian, keylogger.InputEvent{Type: evLed, Code: led, Value: value})
+	binary.Write(device, binary.LittleEndian, keylogger.InputEvent{Type: keylogger.EvSyn})
+}
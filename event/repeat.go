@@ -0,0 +1,104 @@
+package event
+
+import (
+	"sync"
+	"time"
+
+	"github.com/MarinX/keylogger"
+)
+
+var repeatLock sync.RWMutex
+var repeatDelay time.Duration
+var repeatInterval time.Duration
+
+// SetRepeat makes held keys repeat after delay milliseconds, rate times per
+// second. When either is not positive the repeats of the kernel are used.
+func SetRepeat(delay int, rate int) {
+	repeatLock.Lock()
+	defer repeatLock.Unlock()
+
+	if delay <= 0 || rate <= 0 {
+		repeatDelay = 0
+		repeatInterval = 0
+		return
+	}
+	repeatDelay = time.Duration(delay) * time.Millisecond
+	repeatInterval = time.Second / time.Duration(rate)
+}
+
+func repeatSettings() (time.Duration, time.Duration) {
+	repeatLock.RLock()
+	defer repeatLock.RUnlock()
+	return repeatDelay, repeatInterval
+}
+
+// repeater synthesizes repeat events (value 2) for the last pressed key
+type repeater struct {
+	events chan keylogger.InputEvent
+	code   uint16
+	done   chan bool
+}
+
+func newRepeater() *repeater {
+	return &repeater{
+		events: make(chan keylogger.InputEvent),
+	}
+}
+
+func (r *repeater) isSynthesized() bool {
+	delay, _ := repeatSettings()
+	return delay > 0
+}
+
+func (r *repeater) start(code uint16) {
+	r.stop()
+
+	delay, interval := repeatSettings()
+	if delay == 0 {
+		return
+	}
+
+	done := make(chan bool)
+	r.code = code
+	r.done = done
+
+	go func() {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-done:
+			return
+		case <-timer.C:
+		}
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case r.events <- keylogger.InputEvent{Type: keylogger.EvKey, Code: code, Value: 2}:
+			}
+
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// release stops repeating if code is the repeated key
+func (r *repeater) release(code uint16) {
+	if r.done != nil && r.code == code {
+		r.stop()
+	}
+}
+
+func (r *repeater) stop() {
+	if r.done != nil {
+		close(r.done)
+		r.done = nil
+	}
+}
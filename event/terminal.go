@@ -0,0 +1,190 @@
+package event
+
+import (
+	"os"
+	"strings"
+	"syscall"
+	"unicode"
+	"unicode/utf8"
+	"unsafe"
+
+	"github.com/asaskevich/EventBus"
+)
+
+// MakeRaw puts the terminal in raw mode, so every key is read as soon as it
+// is typed, and returns the function restoring the previous mode
+func MakeRaw(tty *os.File) (func(), error) {
+	fd := tty.Fd()
+
+	var previous syscall.Termios
+	if err := ioctl(fd, getTermios, &previous); err != nil {
+		return nil, err
+	}
+
+	raw := previous
+	raw.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP | syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	raw.Oflag &^= syscall.OPOST
+	raw.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cflag &^= syscall.CSIZE | syscall.PARENB
+	raw.Cflag |= syscall.CS8
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+
+	if err := ioctl(fd, setTermios, &raw); err != nil {
+		return nil, err
+	}
+
+	return func() {
+		ioctl(fd, setTermios, &previous)
+	}, nil
+}
+
+func ioctl(fd uintptr, request uintptr, termios *syscall.Termios) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, request, uintptr(unsafe.Pointer(termios)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// escape sequences of the keys without a character
+var terminalSequences = map[string]string{
+	"[A":   "KEY_UP",
+	"[B":   "KEY_DOWN",
+	"[C":   "KEY_RIGHT",
+	"[D":   "KEY_LEFT",
+	"[H":   "KEY_HOME",
+	"[F":   "KEY_END",
+	"[1~":  "KEY_HOME",
+	"[4~":  "KEY_END",
+	"[3~":  "KEY_DEL",
+	"[5~":  "KEY_PAGEUP",
+	"[6~":  "KEY_PAGEDOWN",
+	"OP":   "KEY_F1",
+	"OQ":   "KEY_F2",
+	"OR":   "KEY_F3",
+	"OS":   "KEY_F4",
+	"[15~": "KEY_F5",
+	"[17~": "KEY_F6",
+	"[18~": "KEY_F7",
+	"[19~": "KEY_F8",
+	"[20~": "KEY_F9",
+	"[21~": "KEY_F10",
+	"[23~": "KEY_F11",
+	"[24~": "KEY_F12",
+}
+
+// xterm encodes modifiers as a parameter, like "[1;5A" for Ctrl+Up
+var terminalModifiers = map[string]Modifier{
+	"2": LeftShift,
+	"3": LeftAlt,
+	"4": LeftShift | LeftAlt,
+	"5": LeftCtrl,
+	"6": LeftCtrl | LeftShift,
+	"7": LeftCtrl | LeftAlt,
+	"8": LeftCtrl | LeftShift | LeftAlt,
+}
+
+// BindTerminal publishes the keys typed in a raw mode terminal as KEY events,
+// like BindKeyEvent does for keyboards
+func BindTerminal(tty *os.File, b EventBus.Bus) {
+	buffer := make([]byte, 64)
+	for {
+		n, err := tty.Read(buffer)
+		if err != nil {
+			println("lost terminal input")
+			return
+		}
+		for _, event := range parseTerminalInput(buffer[:n]) {
+			b.Publish("KEY", event)
+		}
+	}
+}
+
+// a read holds whole escape sequences, so a lone escape byte is the escape key
+func parseTerminalInput(input []byte) (events []KeyEvent) {
+	for len(input) > 0 {
+		var event KeyEvent
+		var size int
+
+		if input[0] == 0x1b && len(input) > 1 {
+			event, size = parseEscape(input[1:])
+			size++
+		} else {
+			event, size = parseKey(input)
+		}
+
+		input = input[size:]
+		if event.KeyValue != "" {
+			events = append(events, event)
+		}
+	}
+	return
+}
+
+func parseKey(input []byte) (KeyEvent, int) {
+	switch input[0] {
+	case 0x1b:
+		return KeyEvent{KeyValue: "KEY_ESC"}, 1
+	case 0x7f:
+		return KeyEvent{KeyValue: "KEY_BACKSPACE"}, 1
+	case 0x00:
+		return KeyEvent{Modifiers: LeftCtrl, KeyValue: "KEY_SPACE"}, 1
+	case '\r', '\n':
+		return KeyEvent{KeyValue: "KEY_ENTER"}, 1
+	case '\t':
+		return KeyEvent{KeyValue: "KEY_TAB"}, 1
+	case ' ':
+		return KeyEvent{KeyValue: "KEY_SPACE"}, 1
+	}
+
+	// control characters are Ctrl and a letter, 0x08 being Ctrl+H and not
+	// backspace in most terminals, the others having no key
+	if input[0] > 0x1a && input[0] < 0x20 {
+		return KeyEvent{}, 1
+	}
+	if input[0] < 0x20 {
+		letter := string(rune('a' + input[0] - 1))
+		return KeyEvent{Modifiers: LeftCtrl, IsChar: true, KeyChar: letter, KeyValue: letter}, 1
+	}
+
+	char, size := utf8.DecodeRune(input)
+	event := KeyEvent{IsChar: true, KeyChar: string(char), KeyValue: strings.ToLower(string(char))}
+	if unicode.IsUpper(char) {
+		event.Modifiers = LeftShift
+	}
+	return event, size
+}
+
+func parseEscape(input []byte) (KeyEvent, int) {
+	if input[0] != '[' && input[0] != 'O' {
+		// escape before a key is Alt and that key
+		event, size := parseKey(input)
+		event.Modifiers |= LeftAlt
+		return event, size
+	}
+
+	// a sequence ends with its first letter or tilde, after the introducer
+	end := 1
+	for end < len(input) && !(unicode.IsLetter(rune(input[end])) || input[end] == '~') {
+		end++
+	}
+	if end == len(input) {
+		return KeyEvent{}, len(input)
+	}
+	sequence := string(input[:end+1])
+
+	modifiers := Modifier(0)
+	if parts := strings.SplitN(sequence, ";", 2); len(parts) == 2 {
+		final := sequence[len(sequence)-1:]
+		modifiers = terminalModifiers[strings.TrimRight(parts[1], final)]
+		// "[1;5A" is "[A" and "[3;5~" is "[3~", with modifiers
+		if final == "~" {
+			sequence = parts[0] + final
+		} else {
+			sequence = "[" + final
+		}
+	}
+
+	return KeyEvent{Modifiers: modifiers, KeyValue: terminalSequences[sequence]}, end + 1
+}
@@ -0,0 +1,9 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+// +build darwin dragonfly freebsd netbsd openbsd
+
+package event
+
+import "syscall"
+
+const getTermios = syscall.TIOCGETA
+const setTermios = syscall.TIOCSETA
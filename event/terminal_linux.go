@@ -0,0 +1,6 @@
+package event
+
+import "syscall"
+
+const getTermios = syscall.TCGETS
+const setTermios = syscall.TCSETS
@@ -0,0 +1,44 @@
+package event
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTerminalInput(t *testing.T) {
+	tests := []struct {
+		input  string
+		events []KeyEvent
+	}{
+		{"a", []KeyEvent{{IsChar: true, KeyChar: "a", KeyValue: "a"}}},
+		{"É", []KeyEvent{{Modifiers: LeftShift, IsChar: true, KeyChar: "É", KeyValue: "é"}}},
+		{"a b", []KeyEvent{
+			{IsChar: true, KeyChar: "a", KeyValue: "a"},
+			{KeyValue: "KEY_SPACE"},
+			{IsChar: true, KeyChar: "b", KeyValue: "b"},
+		}},
+		{"\r", []KeyEvent{{KeyValue: "KEY_ENTER"}}},
+		{"\x7f", []KeyEvent{{KeyValue: "KEY_BACKSPACE"}}},
+		{"\x1b", []KeyEvent{{KeyValue: "KEY_ESC"}}},
+		{"\x00", []KeyEvent{{Modifiers: LeftCtrl, KeyValue: "KEY_SPACE"}}},
+		{"\x06", []KeyEvent{{Modifiers: LeftCtrl, IsChar: true, KeyChar: "f", KeyValue: "f"}}},
+		{"\x08", []KeyEvent{{Modifiers: LeftCtrl, IsChar: true, KeyChar: "h", KeyValue: "h"}}},
+		{"\x1c", nil},
+		{"\x1bc", []KeyEvent{{Modifiers: LeftAlt, IsChar: true, KeyChar: "c", KeyValue: "c"}}},
+		{"\x1b[A", []KeyEvent{{KeyValue: "KEY_UP"}}},
+		{"\x1bOP", []KeyEvent{{KeyValue: "KEY_F1"}}},
+		{"\x1b[3~", []KeyEvent{{KeyValue: "KEY_DEL"}}},
+		{"\x1b[1;5D", []KeyEvent{{Modifiers: LeftCtrl, KeyValue: "KEY_LEFT"}}},
+		{"\x1b[3;2~", []KeyEvent{{Modifiers: LeftShift, KeyValue: "KEY_DEL"}}},
+		{"\x1b[A\x1b[B", []KeyEvent{{KeyValue: "KEY_UP"}, {KeyValue: "KEY_DOWN"}}},
+		{"\x1b[99~", nil},
+		{"\x1b[1;5", nil},
+	}
+
+	for _, test := range tests {
+		events := parseTerminalInput([]byte(test.input))
+		if !reflect.DeepEqual(events, test.events) {
+			t.Errorf("%q: got %+v, expected %+v", test.input, events, test.events)
+		}
+	}
+}
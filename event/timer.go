@@ -0,0 +1,30 @@
+package event
+
+import (
+	"time"
+
+	"github.com/asaskevich/EventBus"
+)
+
+// BindTicker publishes a TICK event with the present time every interval, so
+// that views can follow the clock without key presses, until stop is called
+func BindTicker(b EventBus.Bus, interval time.Duration) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan bool)
+
+	go func() {
+		for {
+			select {
+			case now := <-ticker.C:
+				b.Publish("TICK", now)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		ticker.Stop()
+		close(done)
+	}
+}
@@ -1,9 +1,7 @@
 package main
 
 import (
-	"fmt"
 	"os/exec"
-	"time"
 
 	"github.com/MarinX/keylogger"
 	"github.com/asaskevich/EventBus"
@@ -12,34 +10,41 @@ import (
 	"github.com/olup/kobowriter/screener"
 )
 
-func findKeyboard(screen *screener.Screen, bus EventBus.Bus) {
-	// get key logger
-	keyboard := keylogger.FindKeyboardDevice()
+const noKeyboardStatus = "No keyboard, button quits"
 
-	buttonLogger, _ := keylogger.New("/dev/input/event0")
-	buttonChannel := buttonLogger.Read()
+func watchKeyboards(screen *screener.Screen, bus EventBus.Bus) {
+	manager := event.NewInputManager(bus)
 
-	screen.Clear()
+	bus.SubscribeAsync("KEYBOARDS", func(count int) {
+		if count == 0 {
+			screen.SetStatus(noKeyboardStatus)
+		} else {
+			screen.SetStatus("")
+		}
+	}, true)
+
+	manager.Start()
+	if manager.Count() == 0 {
+		screen.SetStatus(noKeyboardStatus)
+	}
+
+	go watchButton(manager, bus)
+}
 
-	for len(keyboard) <= 0 {
-		screen.PrintAlert("No keyboard found.\n\nPlug your keyboard or clic main button to quit.\n\nNote that [USB OTG MODE] must be turned on in order to detect the keyboard.", 30)
-		time.Sleep(1 * time.Second)
-		select {
-		case _ = <-buttonChannel:
+// the main button quits the program, but only when no keyboard is plugged as
+// it may otherwise be pressed by mistake while writing
+func watchButton(manager *event.InputManager, bus EventBus.Bus) {
+	buttonLogger, err := keylogger.New("/dev/input/event0")
+	if err != nil {
+		return
+	}
+
+	for range buttonLogger.Read() {
+		if manager.Count() == 0 {
 			println("Quitting program")
 			bus.Publish("QUIT")
 			exec.Command("/opt/xcsoar/bin/KoboMenu").Start()
 			return
-		default:
 		}
-		keyboard = keylogger.FindKeyboardDevice()
 	}
-
-	screen.Clear()
-	fmt.Println("Found a keyboard at", keyboard)
-
-	k, _ := keylogger.New(keyboard)
-	go event.BindKeyEvent(k, bus)
-	bus.Publish("ROUTING", "document")
-	return
 }
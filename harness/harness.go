@@ -0,0 +1,402 @@
+// Package harness replays scripts of key events against the views, with a
+// headless display, and checks what ends up on screen and on disk.
+//
+// A script has one command per line, arguments may use \n for new lines, or
+// be quoted to keep surrounding spaces:
+//
+//	type Hello world        types text on the keys of the current layout
+//	key Ctrl+KEY_UP         presses a key, written like keybindings
+//	keycode Shift+16        presses the key of an evdev code
+//	route menu              publishes a ROUTING event
+//	tick 5m                 moves the clock forward, publishing a TICK event
+//	expect-line Hello       a line of the screen contains the text
+//	expect-no-line Hello    no line of the screen contains the text
+//	expect-row 3 Hello      the line of the screen counted from 0 contains the text
+//	expect-inverted Hello   a run of inverted cells of a line contains the text
+//	expect-no-inverted Hello no run of inverted cells contains the text
+//	expect-document Hello   the opened document has exactly this content, once
+//	                        the clock moved past the pause saving it
+//	expect-documents 2      number of documents in the save location
+//	expect-images 1         number of images displayed since the last clear
+//	expect-quit             the program has been asked to quit
+//
+// Empty lines and lines starting with # are ignored.
+package harness
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MarinX/keylogger"
+	"github.com/asaskevich/EventBus"
+	"github.com/olup/kobowriter/event"
+	"github.com/olup/kobowriter/matrix"
+	"github.com/olup/kobowriter/screener"
+	"github.com/olup/kobowriter/utils"
+	"github.com/olup/kobowriter/views"
+)
+
+const screenWidth = 60
+const screenHeight = 24
+
+// Session is a running program, with a headless display and the events of a
+// keyboard sent by the scripts
+type Session struct {
+	Display      *screener.Headless
+	Bus          EventBus.Bus
+	SaveLocation string
+
+	events chan keylogger.InputEvent
+
+	lock sync.Mutex
+	quit bool
+	// added to the present time in TICK events
+	elapsed time.Duration
+}
+
+func NewSession(saveLocation string) *Session {
+	s := &Session{
+		Display:      screener.NewHeadless(screenWidth, screenHeight),
+		Bus:          EventBus.New(),
+		SaveLocation: saveLocation,
+		events:       make(chan keylogger.InputEvent),
+	}
+
+	event.SetLayout(utils.LoadConfig(saveLocation).KeyboardLayout)
+	go event.BindKeyEvent(s.events, event.NewLeds(), s.Bus)
+
+	screen := screener.InitScreen(s.Display)
+	views.Router(screen, s.Bus, saveLocation)
+	s.Bus.SubscribeAsync("QUIT", func() {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		s.quit = true
+	}, false)
+
+	s.Route("document")
+	return s
+}
+
+// Route publishes a ROUTING event and waits for the view to be displayed
+func (s *Session) Route(name string) {
+	s.Bus.Publish("ROUTING", name)
+	s.Bus.WaitAsync()
+}
+
+// Tick moves the clock of the views forward, and waits for the TICK event to
+// be handled
+func (s *Session) Tick(duration time.Duration) {
+	s.elapsed += duration
+	s.Bus.Publish("TICK", time.Now().Add(s.elapsed))
+	s.Bus.WaitAsync()
+}
+
+// Key presses a key, written like keybindings, and waits for it to be handled
+func (s *Session) Key(raw string) error {
+	binding, err := event.ParseBinding(raw)
+	if err != nil {
+		return err
+	}
+
+	layout := event.CurrentLayout()
+	code, ok := keyCode(binding.Key)
+	if !ok {
+		// characters are typed on the key giving them on the current layout
+		var shift, altGr bool
+		if code, shift, altGr, ok = findChar(layout, binding.Key); !ok {
+			return fmt.Errorf("%q is not on the %s layout", binding.Key, layout.Label)
+		}
+		binding.Shift = binding.Shift || shift
+		binding.AltGr = binding.AltGr || altGr
+	}
+
+	s.press(code, modifierCodes(binding)...)
+	return nil
+}
+
+// KeyCode presses the key of an evdev code, with modifiers written like
+// keybindings, as in Shift+16
+func (s *Session) KeyCode(raw string) error {
+	binding, err := event.ParseBinding(raw)
+	if err != nil {
+		return err
+	}
+	code, err := strconv.Atoi(binding.Key)
+	if err != nil || code <= 0 || code > 255 {
+		return fmt.Errorf("%q is not an evdev code", binding.Key)
+	}
+	s.press(code, modifierCodes(binding)...)
+	return nil
+}
+
+// Type types text on the keys of the current layout, spaces and new lines
+// being KEY_SPACE and KEY_ENTER
+func (s *Session) Type(text string) error {
+	layout := event.CurrentLayout()
+	for _, char := range text {
+		switch char {
+		case ' ':
+			s.press(57)
+		case '\n':
+			s.press(28)
+		case '\t':
+			s.press(15)
+		default:
+			code, shift, altGr, ok := findChar(layout, string(char))
+			if !ok {
+				return fmt.Errorf("%q is not on the %s layout", char, layout.Label)
+			}
+			s.press(code, modifierCodes(event.Binding{Shift: shift, AltGr: altGr})...)
+			// dead keys give their own character when followed by a space
+			if layout.DeadKeys[string(char)] {
+				s.press(57)
+			}
+		}
+	}
+	return nil
+}
+
+// press sends a key press and release to the keyboard events, the modifiers
+// being held around it, and waits for the keys to be handled
+func (s *Session) press(code int, modifiers ...int) {
+	for _, modifier := range modifiers {
+		s.input(modifier, 1)
+	}
+	s.input(code, 1)
+	s.input(code, 0)
+	for i := len(modifiers) - 1; i >= 0; i-- {
+		s.input(modifiers[i], 0)
+	}
+	s.Bus.WaitAsync()
+}
+
+// each key event is followed by a report, as from the kernel, which is read
+// once the key event has been handled
+func (s *Session) input(code int, value int32) {
+	s.events <- keylogger.InputEvent{Type: keylogger.EvKey, Code: uint16(code), Value: value}
+	s.events <- keylogger.InputEvent{Type: keylogger.EvSyn}
+}
+
+// Close stops reading the keyboard events
+func (s *Session) Close() {
+	close(s.events)
+}
+
+func keyCode(name string) (int, bool) {
+	if !strings.HasPrefix(name, "KEY_") {
+		return 0, false
+	}
+	for code := 1; code < 256; code++ {
+		if event.KeyCode[code] == name {
+			return code, true
+		}
+	}
+	return 0, false
+}
+
+// findChar returns the key giving a character on a layout, and the modifiers
+// it needs
+func findChar(layout *event.Layout, char string) (code int, shift bool, altGr bool, ok bool) {
+	levels := []map[int]string{layout.Base, layout.Shift, layout.AltGr, layout.ShiftAltGr}
+	for i, level := range levels {
+		for code := 1; code < 256; code++ {
+			if level[code] == char {
+				return code, i == 1 || i == 3, i >= 2, true
+			}
+		}
+	}
+	return 0, false, false, false
+}
+
+func modifierCodes(binding event.Binding) (codes []int) {
+	for _, modifier := range []struct {
+		held bool
+		code int
+	}{
+		{binding.Ctrl, 29},
+		{binding.Shift, 42},
+		{binding.Alt, 56},
+		{binding.AltGr, 100},
+		{binding.Meta, 125},
+	} {
+		if modifier.held {
+			codes = append(codes, modifier.code)
+		}
+	}
+	return
+}
+
+// Lines returns the lines of the screen, without trailing spaces
+func (s *Session) Lines() []string {
+	screenMatrix := s.Display.Matrix()
+	lines := make([]string, len(screenMatrix))
+	for i := range screenMatrix {
+		lines[i] = strings.TrimRight(matrix.MatrixToText(screenMatrix[i:i+1]), " ")
+	}
+	return lines
+}
+
+func (s *Session) hasLine(text string) bool {
+	for _, line := range s.Lines() {
+		if strings.Contains(line, text) {
+			return true
+		}
+	}
+	return false
+}
+
+// InvertedRuns returns the text of the consecutive inverted cells of each line
+func (s *Session) InvertedRuns() (runs []string) {
+	for _, row := range s.Display.Matrix() {
+		run := ""
+		for _, cell := range append(row, matrix.MatrixElement{}) {
+			if cell.IsInverted {
+				run += string(cell.Content)
+			} else if run != "" {
+				runs = append(runs, run)
+				run = ""
+			}
+		}
+	}
+	return
+}
+
+func (s *Session) Document() string {
+	config := utils.LoadConfig(s.SaveLocation)
+	content, _ := os.ReadFile(config.LastOpenedDocument)
+	return string(content)
+}
+
+func (s *Session) Documents() int {
+	files, _ := filepath.Glob(path.Join(s.SaveLocation, "*.txt"))
+	return len(files)
+}
+
+func (s *Session) HasQuit() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.quit
+}
+
+// Run replays a script in a new session saving in an empty directory
+func Run(script io.Reader) error {
+	saveLocation, err := os.MkdirTemp("", "kobowriter")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(saveLocation)
+
+	s := NewSession(saveLocation)
+	defer s.Close()
+
+	scanner := bufio.NewScanner(script)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		command := strings.SplitN(line, " ", 2)
+		argument := ""
+		if len(command) == 2 {
+			argument = strings.NewReplacer(`\n`, "\n", `\t`, "\t", `\\`, `\`).Replace(command[1])
+			if quoted, err := strconv.Unquote(command[1]); err == nil {
+				argument = quoted
+			}
+		}
+
+		if err := s.execute(command[0], argument); err != nil {
+			return fmt.Errorf("line %d: %s: %v\n%s", lineNumber, line, err, strings.Join(s.Lines(), "\n"))
+		}
+	}
+
+	return scanner.Err()
+}
+
+func (s *Session) execute(command string, argument string) error {
+	switch command {
+	case "type":
+		return s.Type(argument)
+	case "key":
+		return s.Key(argument)
+	case "keycode":
+		return s.KeyCode(argument)
+	case "route":
+		s.Route(argument)
+	case "tick":
+		duration, err := time.ParseDuration(argument)
+		if err != nil {
+			return err
+		}
+		s.Tick(duration)
+	case "expect-line":
+		if !s.hasLine(argument) {
+			return fmt.Errorf("no line contains %q", argument)
+		}
+	case "expect-no-line":
+		if s.hasLine(argument) {
+			return fmt.Errorf("a line contains %q", argument)
+		}
+	case "expect-row":
+		fields := strings.SplitN(argument, " ", 2)
+		row, err := strconv.Atoi(fields[0])
+		if err != nil || len(fields) < 2 {
+			return fmt.Errorf("%q is not a row and a text", argument)
+		}
+		if lines := s.Lines(); row >= len(lines) || !strings.Contains(lines[row], fields[1]) {
+			return fmt.Errorf("row %d does not contain %q", row, fields[1])
+		}
+	case "expect-inverted":
+		found := false
+		for _, run := range s.InvertedRuns() {
+			found = found || strings.Contains(run, argument)
+		}
+		if !found {
+			return fmt.Errorf("no inverted cells contain %q", argument)
+		}
+	case "expect-no-inverted":
+		for _, run := range s.InvertedRuns() {
+			if strings.Contains(run, argument) {
+				return fmt.Errorf("inverted cells contain %q", argument)
+			}
+		}
+	case "expect-document":
+		// documents are saved once typing pauses
+		s.Tick(views.SaveDelay)
+		if document := s.Document(); document != argument {
+			return fmt.Errorf("document is %q", document)
+		}
+	case "expect-documents":
+		return expectCount("documents", s.Documents(), argument)
+	case "expect-images":
+		return expectCount("images", len(s.Display.Images()), argument)
+	case "expect-quit":
+		if !s.HasQuit() {
+			return fmt.Errorf("the program did not quit")
+		}
+	default:
+		return fmt.Errorf("unknown command %q", command)
+	}
+	return nil
+}
+
+func expectCount(name string, count int, argument string) error {
+	expected, err := strconv.Atoi(argument)
+	if err != nil {
+		return fmt.Errorf("%q is not a number", argument)
+	}
+	if count != expected {
+		return fmt.Errorf("%d %s, expected %d", count, name, expected)
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package harness
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// the scripts run one after the other, the views keeping some state between
+// sessions like the clipboard
+func TestScripts(t *testing.T) {
+	scripts, err := filepath.Glob(filepath.Join("scripts", "*.txt"))
+	if err != nil || len(scripts) == 0 {
+		t.Fatal("no scripts found", err)
+	}
+
+	for _, scriptPath := range scripts {
+		scriptPath := scriptPath
+		t.Run(strings.TrimSuffix(filepath.Base(scriptPath), ".txt"), func(t *testing.T) {
+			script, err := os.Open(scriptPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer script.Close()
+
+			if err := Run(script); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -1,13 +1,17 @@
 package main
 
 import (
+	"flag"
 	"fmt"
-	"os/exec"
+	"os"
+	"path"
+	"time"
 
 	"github.com/asaskevich/EventBus"
 
 	_ "embed"
 
+	"github.com/olup/kobowriter/event"
 	"github.com/olup/kobowriter/screener"
 	"github.com/olup/kobowriter/utils"
 	"github.com/olup/kobowriter/views"
@@ -16,32 +20,75 @@ import (
 var saveLocation = "/mnt/onboard/.adds/kobowriter"
 var filename = "autosave.txt"
 
+var displayName = flag.String("display", "fbink", "where to display: fbink on the kobo, or terminal")
+
 func main() {
-	fmt.Println("Program started")
+	flag.StringVar(&saveLocation, "dir", saveLocation, "directory of the documents and settings")
+	flag.Parse()
 
-	// kill all nickel related stuff. Will need a reboot to find back the usual
-	fmt.Println("Killing XCSoar programs ...")
-	exec.Command("killall", "-s", "SIGKILL", "KoboMenu").Run()
+	fmt.Println("Program started")
 
-	// rotate screen
-	fmt.Println("Rotate screen ...")
-	exec.Command(`fbdepth`, `--rota`, `2`).Run()
+	if err := os.MkdirAll(saveLocation, 0755); err != nil {
+		fmt.Println("Cannot use the save location:", err)
+		os.Exit(1)
+	}
 
-	// initialise fbink
-	fmt.Println("Init FBInk ...")
+	var display screener.Display
+	var tty *os.File
+	switch *displayName {
+	case "fbink":
+		var err error
+		display, err = openFbink()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	case "terminal":
+		var err error
+		var restore func()
+		tty, err = os.OpenFile("/dev/tty", os.O_RDWR, 0)
+		if err == nil {
+			restore, err = event.MakeRaw(tty)
+		}
+		if err != nil {
+			fmt.Println("Cannot use the terminal:", err)
+			os.Exit(1)
+		}
+		defer restore()
+		logToFile(path.Join(saveLocation, "kobowriter.log"))
+		display = screener.NewTerminal(tty)
+	default:
+		fmt.Println("Unknown display", *displayName)
+		os.Exit(1)
+	}
 
-	screen := screener.InitScreen()
+	screen := screener.InitScreen(display)
 	defer screen.Clean()
 
 	bus := EventBus.New()
 
+	config := utils.LoadConfig(saveLocation)
+
+	errs := event.LoadKeymaps(saveLocation)
+	if err := event.LoadCompose(saveLocation); err != nil {
+		errs = append(errs, err)
+	}
+	errs = append(errs, event.SetKeybindings(config.Keybindings)...)
+	if len(errs) > 0 {
+		message := "Some keyboard settings could not be loaded:\n"
+		for _, err := range errs {
+			message += "\n" + err.Error()
+		}
+		screen.PrintAlert(message, 40)
+		time.Sleep(5 * time.Second)
+		screen.Clear()
+	}
+	event.SetLayout(config.KeyboardLayout)
+	event.SetRepeat(config.RepeatDelay, config.RepeatRate)
+
 	c := make(chan bool)
 	defer close(c)
 
-	bus.SubscribeAsync("REQUIRE_KEYBOARD", func() {
-		findKeyboard(screen, bus)
-	}, false)
-
 	bus.SubscribeAsync("QUIT", func() {
 		screen.PrintAlert("Good Bye !", 500)
 
@@ -50,33 +97,18 @@ func main() {
 		return
 	}, false)
 
-	var unmount func()
-	bus.SubscribeAsync("ROUTING", func(routeName string) {
-		if unmount != nil {
-			unmount()
-		}
-
-		switch routeName {
-		case "document":
-			config := utils.LoadConfig(saveLocation)
-			unmount = views.Document(screen, bus, config.LastOpenedDocument)
-		case "menu":
-			unmount = views.MainMenu(screen, bus, saveLocation)
-		case "file-menu":
-			unmount = views.FileMenu(screen, bus, saveLocation)
-		case "settings-menu":
-			unmount = views.SettingsMenu(screen, bus, saveLocation)
-		case "qr":
-			unmount = views.Qr(screen, bus, saveLocation)
-
-		default:
-			unmount = views.Document(screen, bus, "")
-		}
-
-	}, false)
+	views.Router(screen, bus, saveLocation)
 
 	// init
-	bus.Publish("REQUIRE_KEYBOARD")
+	if *displayName == "terminal" {
+		go event.BindTerminal(tty, bus)
+	} else {
+		watchKeyboards(screen, bus)
+	}
+	stopTicker := event.BindTicker(bus, time.Second)
+	defer stopTicker()
+
+	bus.Publish("ROUTING", "document")
 
 	for quit := range c {
 		if quit {
@@ -87,3 +119,13 @@ func main() {
 	println("yo")
 
 }
+
+// the terminal is used for display, so logs go to a file
+func logToFile(logPath string) {
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return
+	}
+	redirect(logFile, os.Stdout)
+	redirect(logFile, os.Stderr)
+}
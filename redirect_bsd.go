@@ -0,0 +1,14 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+// +build darwin dragonfly freebsd netbsd openbsd
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// redirect makes the writes to file go to target, including the ones of println
+func redirect(target *os.File, file *os.File) {
+	syscall.Dup2(int(target.Fd()), int(file.Fd()))
+}
@@ -0,0 +1,11 @@
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// redirect makes the writes to file go to target, including the ones of println
+func redirect(target *os.File, file *os.File) {
+	syscall.Dup3(int(target.Fd()), int(file.Fd()), 0)
+}
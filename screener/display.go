@@ -0,0 +1,15 @@
+package screener
+
+import "github.com/olup/kobowriter/matrix"
+
+// Display is the device showing the screen, see the fbink package for the
+// kobo one and Headless for an in-memory one
+type Display interface {
+	// in characters
+	Size() (width int, height int)
+	// previous is the matrix presently displayed, so only changes are drawn
+	Print(previous matrix.Matrix, next matrix.Matrix)
+	PrintPng(imgBytes []byte, w int, h int, x int, y int)
+	Clear(flash bool)
+	Close()
+}
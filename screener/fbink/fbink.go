@@ -0,0 +1,142 @@
+package fbink
+
+import (
+	"bytes"
+	"image"
+
+	"github.com/fogleman/gg"
+	"github.com/olup/kobowriter/matrix"
+	"github.com/shermp/go-fbink-v2/gofbink"
+)
+
+// Display drives the e-ink screen of the kobo through FBInk
+type Display struct {
+	fb       *gofbink.FBInk
+	state    gofbink.FBInkState
+	width    int
+	height   int
+	fontType string
+	ttSize   int
+}
+
+var dc = gg.NewContext(25, 40)
+var charCache = map[string][]byte{}
+
+func Open() (d *Display) {
+	d = &Display{}
+	d.fontType = "bitmap"
+
+	d.state = gofbink.FBInkState{}
+
+	fbinkOpts := gofbink.FBInkConfig{}
+	rOpts := gofbink.RestrictedConfig{
+		Fontmult: 3,
+		Fontname: gofbink.Ctrld,
+	}
+	d.fb = gofbink.New(&fbinkOpts, &rOpts)
+
+	d.fb.Open()
+	d.fb.Init(&fbinkOpts)
+	d.fb.AddOTfont("/mnt/onboard/.adds/kobowriter/inc.ttf", gofbink.FntRegular)
+
+	d.fb.GetState(&fbinkOpts, &d.state)
+
+	if d.fontType == "truetype" {
+		dc.LoadFontFace("inc.ttf", 96)
+		d.ttSize = 40
+		d.width = int(d.state.ScreenWidth) / ((d.ttSize / 5) * 3)
+		d.height = int(d.state.ScreenHeight) / d.ttSize
+	} else {
+		d.width = int(d.state.MaxCols)
+		d.height = int(d.state.MaxRows)
+	}
+
+	return
+}
+
+func (d *Display) Size() (int, int) {
+	return d.width, d.height
+}
+
+func (d *Display) Close() {
+	d.fb.Close()
+}
+
+func same(a matrix.MatrixElement, b matrix.MatrixElement) bool {
+	return a.Content == b.Content && a.IsInverted == b.IsInverted
+}
+
+func (d *Display) Print(previous matrix.Matrix, next matrix.Matrix) {
+	fb := d.fb
+	ttSize := d.ttSize
+	for i := range previous {
+		for j := range previous[i] {
+			if !same(previous[i][j], next[i][j]) {
+				if d.fontType == "truetype" {
+					ttWidth := ((ttSize / 5) * 3)
+					fb.ClearScreen(&gofbink.FBInkConfig{
+						IsInverted: next[i][j].IsInverted,
+						NoRefresh:  true,
+					}, &gofbink.FBInkRect{
+						Top:    uint16(i * ttSize),
+						Left:   uint16(j * ttWidth),
+						Height: uint16(ttSize),
+						Width:  uint16(ttWidth),
+					})
+
+					fb.PrintOT(string(next[i][j].Content), &gofbink.FBInkOTConfig{
+						Margins: struct {
+							Top    int16
+							Bottom int16
+							Left   int16
+							Right  int16
+						}{
+							Top:  int16(i * ttSize),
+							Left: int16(j * ttWidth),
+						},
+						SizePx:      uint16(ttSize),
+						IsFormatted: false,
+					}, &gofbink.FBInkConfig{IsInverted: next[i][j].IsInverted, NoRefresh: true})
+
+				} else {
+					fb.FBprint(string(next[i][j].Content), &gofbink.FBInkConfig{
+						Row:        int16(i),
+						Col:        int16(j),
+						NoRefresh:  true,
+						IsInverted: next[i][j].IsInverted,
+					})
+				}
+
+			}
+
+		}
+	}
+
+	fb.Refresh(0, 0, 0, 0, &gofbink.FBInkConfig{})
+}
+
+func (d *Display) PrintPng(imgBytes []byte, w int, h int, x int, y int) {
+	img, _, _ := image.Decode(bytes.NewReader(imgBytes))
+	buffer, _ := getPixelsFromImage(img)
+	d.fb.PrintRawData(buffer, w, h, uint16(x), uint16(y), &gofbink.FBInkConfig{})
+}
+
+func getCharImage(s string) []byte {
+	if char, ok := charCache[s]; ok {
+		return char
+	} else {
+		dc.SetRGB(1, 1, 1)
+		dc.Clear()
+
+		dc.SetRGB(0, 0, 0)
+		dc.DrawString(s, 0, 35)
+		img := dc.Image()
+		buffer, _ := getPixelsFromImage(img)
+		charCache[s] = buffer
+		return buffer
+	}
+}
+
+func (d *Display) Clear(flash bool) {
+	d.fb.ClearScreen(&gofbink.FBInkConfig{IsFlashing: flash}, &gofbink.FBInkRect{})
+}
@@ -1,4 +1,4 @@
-package screener
+package fbink
 
 import (
 	"image"
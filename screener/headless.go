@@ -0,0 +1,92 @@
+package screener
+
+import (
+	"sync"
+
+	"github.com/olup/kobowriter/matrix"
+)
+
+type Image struct {
+	Data   []byte
+	Width  int
+	Height int
+	X      int
+	Y      int
+}
+
+// Headless is a display keeping what would be shown in memory, to run the
+// program off the device
+type Headless struct {
+	lock    sync.Mutex
+	width   int
+	height  int
+	matrix  matrix.Matrix
+	images  []Image
+	flashes int
+}
+
+func NewHeadless(width int, height int) *Headless {
+	return &Headless{
+		width:  width,
+		height: height,
+		matrix: matrix.CreateNewMatrix(width, height),
+	}
+}
+
+func (h *Headless) Size() (int, int) {
+	return h.width, h.height
+}
+
+func (h *Headless) Print(previous matrix.Matrix, next matrix.Matrix) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.matrix = matrix.CopyMatrix(next)
+}
+
+func (h *Headless) PrintPng(imgBytes []byte, w int, height int, x int, y int) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.images = append(h.images, Image{
+		Data:   imgBytes,
+		Width:  w,
+		Height: height,
+		X:      x,
+		Y:      y,
+	})
+}
+
+func (h *Headless) Clear(flash bool) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.matrix = matrix.FillMatrix(matrix.CreateNewMatrix(h.width, h.height), ' ')
+	h.images = nil
+	if flash {
+		h.flashes++
+	}
+}
+
+func (h *Headless) Close() {}
+
+// Matrix returns a copy of what is displayed
+func (h *Headless) Matrix() matrix.Matrix {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return matrix.CopyMatrix(h.matrix)
+}
+
+func (h *Headless) Text() string {
+	return matrix.MatrixToText(h.Matrix())
+}
+
+// Images returns the images displayed since the last clear
+func (h *Headless) Images() []Image {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return append([]Image{}, h.images...)
+}
+
+func (h *Headless) Flashes() int {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.flashes
+}
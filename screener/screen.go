@@ -1,156 +1,104 @@
 package screener
 
 import (
-	"bytes"
-	"image"
 	"math"
+	"sync"
 
-	"github.com/fogleman/gg"
 	"github.com/olup/kobowriter/matrix"
-	"github.com/shermp/go-fbink-v2/gofbink"
+	"github.com/olup/kobowriter/utils"
 )
 
 type Screen struct {
 	originalMatrix matrix.Matrix
 	presentMatrix  matrix.Matrix
-	fb             *gofbink.FBInk
-	state          gofbink.FBInkState
+	display        Display
 	Width          int
 	Height         int
-	fontType       string
-	ttSize         int
+	viewMatrix     matrix.Matrix
+	status         string
+	statusBar      string
+	lock           sync.Mutex
 }
 
-var dc = gg.NewContext(25, 40)
-var charCache = map[string][]byte{}
-
-func InitScreen() (s *Screen) {
+func InitScreen(display Display) (s *Screen) {
 	s = &Screen{}
-	s.fontType = "bitmap"
-
-	s.state = gofbink.FBInkState{}
-
-	fbinkOpts := gofbink.FBInkConfig{}
-	rOpts := gofbink.RestrictedConfig{
-		Fontmult: 3,
-		Fontname: gofbink.Ctrld,
-	}
-	s.fb = gofbink.New(&fbinkOpts, &rOpts)
+	s.display = display
+	s.Width, s.Height = display.Size()
 
-	s.fb.Open()
-	s.fb.Init(&fbinkOpts)
-	s.fb.AddOTfont("/mnt/onboard/.adds/kobowriter/inc.ttf", gofbink.FntRegular)
-
-	s.fb.GetState(&fbinkOpts, &s.state)
+	s.presentMatrix = matrix.CreateNewMatrix(s.Width, s.Height)
+	s.originalMatrix = matrix.CreateNewMatrix(s.Width, s.Height)
 
 	// clear screen on initialisation
 	s.ClearFlash()
 
-	if s.fontType == "truetype" {
-		dc.LoadFontFace("inc.ttf", 96)
-		s.ttSize = 40
-		s.Width = int(s.state.ScreenWidth) / ((s.ttSize / 5) * 3)
-		s.Height = int(s.state.ScreenHeight) / s.ttSize
-	} else {
-		s.Width = int(s.state.MaxCols)
-		s.Height = int(s.state.MaxRows)
-	}
-
-	s.presentMatrix = matrix.CreateNewMatrix(s.Width, s.Height)
-	s.originalMatrix = matrix.CreateNewMatrix(s.Width, s.Height)
-
 	println("Screen struct inited")
 
 	return
-
 }
 
 func (s *Screen) Clean() {
-	s.fb.Close()
+	s.display.Close()
 }
 
 func (s *Screen) Print(matrix matrix.Matrix) {
-	printDiff(s.presentMatrix, matrix, s.fb, s.fontType, s.ttSize)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.viewMatrix = matrix
+	s.print(s.withStatus(matrix))
+}
+
+func (s *Screen) print(matrix matrix.Matrix) {
+	s.display.Print(s.presentMatrix, matrix)
 	s.presentMatrix = matrix
 }
 
-func same(a matrix.MatrixElement, b matrix.MatrixElement) bool {
-	return a.Content == b.Content && a.IsInverted == b.IsInverted
+// SetStatus shows a short message in the top right corner of the screen, over
+// whatever is displayed, until it is set back to an empty string
+func (s *Screen) SetStatus(status string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.status = status
+	if s.viewMatrix != nil {
+		s.print(s.withStatus(s.viewMatrix))
+	}
 }
 
-func printDiff(previous matrix.Matrix, next matrix.Matrix, fb *gofbink.FBInk, fontType string, ttSize int) {
-	for i := range previous {
-		for j := range previous[i] {
-			if !same(previous[i][j], next[i][j]) {
-				if fontType == "truetype" {
-					ttWidth := ((ttSize / 5) * 3)
-					fb.ClearScreen(&gofbink.FBInkConfig{
-						IsInverted: next[i][j].IsInverted,
-						NoRefresh:  true,
-					}, &gofbink.FBInkRect{
-						Top:    uint16(i * ttSize),
-						Left:   uint16(j * ttWidth),
-						Height: uint16(ttSize),
-						Width:  uint16(ttWidth),
-					})
-
-					fb.PrintOT(string(next[i][j].Content), &gofbink.FBInkOTConfig{
-						Margins: struct {
-							Top    int16
-							Bottom int16
-							Left   int16
-							Right  int16
-						}{
-							Top:  int16(i * ttSize),
-							Left: int16(j * ttWidth),
-						},
-						SizePx:      uint16(ttSize),
-						IsFormatted: false,
-					}, &gofbink.FBInkConfig{IsInverted: next[i][j].IsInverted, NoRefresh: true})
-
-				} else {
-					fb.FBprint(string(next[i][j].Content), &gofbink.FBInkConfig{
-						Row:        int16(i),
-						Col:        int16(j),
-						NoRefresh:  true,
-						IsInverted: next[i][j].IsInverted,
-					})
-				}
-
-			}
+// SetStatusBar shows a line at the bottom of the screen from the next print,
+// until it is set back to an empty string
+func (s *Screen) SetStatusBar(bar string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.statusBar = bar
+}
 
+func (s *Screen) withStatus(in matrix.Matrix) matrix.Matrix {
+	if len(in) == 0 {
+		return in
+	}
+	if s.statusBar != "" {
+		width := len(in[0]) - 4
+		bar := []rune(s.statusBar)
+		if len(bar) > width {
+			bar = bar[:width]
 		}
+		in = matrix.PasteMatrix(in, matrix.CreateMatrixFromText(string(bar), width), 2, len(in)-1)
 	}
-
-	fb.Refresh(0, 0, 0, 0, &gofbink.FBInkConfig{})
+	if s.status == "" {
+		return in
+	}
+	status := " " + s.status + " "
+	statusMatrix := matrix.InverseMatrix(matrix.CreateMatrixFromText(status, utils.LenString(status)))
+	return matrix.PasteMatrix(in, statusMatrix, len(in[0])-utils.LenString(status)-1, 0)
 }
 
 func (s *Screen) PrintPng(imgBytes []byte, w int, h int, x int, y int) {
-	img, _, _ := image.Decode(bytes.NewReader(imgBytes))
-	buffer, _ := getPixelsFromImage(img)
-	s.fb.PrintRawData(buffer, w, h, uint16(x), uint16(y), &gofbink.FBInkConfig{})
-}
-
-func getCharImage(s string) []byte {
-	if char, ok := charCache[s]; ok {
-		return char
-	} else {
-		dc.SetRGB(1, 1, 1)
-		dc.Clear()
-
-		dc.SetRGB(0, 0, 0)
-		dc.DrawString(s, 0, 35)
-		img := dc.Image()
-		buffer, _ := getPixelsFromImage(img)
-		charCache[s] = buffer
-		return buffer
-	}
+	s.display.PrintPng(imgBytes, w, h, x, y)
 }
 
 func (s *Screen) PrintAlert(message string, width int) {
 	thisMatrix := matrix.CreateMatrixFromText(message, width)
-	x := math.Floor((float64(s.state.MaxCols)/2)-float64(width)/2) - 1
-	y := math.Floor((float64(s.state.MaxRows)/2)-float64(len(thisMatrix))/2) - 1
+	x := math.Floor((float64(s.Width)/2)-float64(width)/2) - 1
+	y := math.Floor((float64(s.Height)/2)-float64(len(thisMatrix))/2) - 1
 	outerMatrix := matrix.CreateNewMatrix(width+2, len(thisMatrix)+2)
 	thisMatrix = matrix.PasteMatrix(outerMatrix, thisMatrix, 1, 1)
 	thisMatrix = matrix.InverseMatrix(thisMatrix)
@@ -158,19 +106,30 @@ func (s *Screen) PrintAlert(message string, width int) {
 }
 
 func (s *Screen) Clear() {
-	s.fb.ClearScreen(&gofbink.FBInkConfig{}, &gofbink.FBInkRect{})
-	s.presentMatrix = matrix.FillMatrix(s.presentMatrix, ' ')
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.clear(false)
 }
 
 func (s *Screen) ClearFlash() {
-	s.fb.ClearScreen(&gofbink.FBInkConfig{IsFlashing: true}, &gofbink.FBInkRect{})
-	s.presentMatrix = matrix.FillMatrix(s.presentMatrix, ' ')
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.clear(true)
 }
 
+// RefreshFlash clears the screen with a flash and prints it again, the lock
+// held all along for no print to come in between
 func (s *Screen) RefreshFlash() {
-	presenMatrix := s.presentMatrix
-	s.ClearFlash()
-	s.Print(presenMatrix)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	presentMatrix := s.presentMatrix
+	s.clear(true)
+	s.print(presentMatrix)
+}
+
+func (s *Screen) clear(flash bool) {
+	s.display.Clear(flash)
+	s.presentMatrix = matrix.FillMatrix(s.presentMatrix, ' ')
 }
 
 func (s *Screen) GetOriginalMatrix() matrix.Matrix {
@@ -0,0 +1,114 @@
+package screener
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/png"
+	"io"
+	"os"
+	"syscall"
+	"unsafe"
+
+	"github.com/olup/kobowriter/matrix"
+)
+
+// Terminal displays the screen in a terminal with ANSI escape codes, inverted
+// cells being shown in reverse video
+type Terminal struct {
+	out    io.Writer
+	width  int
+	height int
+}
+
+func NewTerminal(tty *os.File) *Terminal {
+	t := &Terminal{out: tty, width: 80, height: 24}
+
+	var size struct {
+		rows, cols, x, y uint16
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, tty.Fd(), syscall.TIOCGWINSZ, uintptr(unsafe.Pointer(&size)))
+	if errno == 0 && size.cols > 0 && size.rows > 0 {
+		t.width = int(size.cols)
+		t.height = int(size.rows)
+	}
+
+	// hide the cursor, the views draw their own
+	fmt.Fprint(t.out, "\x1b[?25l")
+	return t
+}
+
+func (t *Terminal) Size() (int, int) {
+	return t.width, t.height
+}
+
+func (t *Terminal) Print(previous matrix.Matrix, next matrix.Matrix) {
+	var buffer bytes.Buffer
+	for i := range next {
+		for j := range next[i] {
+			if i < len(previous) && j < len(previous[i]) && previous[i][j] == next[i][j] {
+				continue
+			}
+			fmt.Fprintf(&buffer, "\x1b[%d;%dH", i+1, j+1)
+			if next[i][j].IsInverted {
+				buffer.WriteString("\x1b[7m")
+			} else {
+				buffer.WriteString("\x1b[27m")
+			}
+			buffer.WriteRune(next[i][j].Content)
+		}
+	}
+	buffer.WriteString("\x1b[0m")
+	t.out.Write(buffer.Bytes())
+}
+
+// PrintPng draws the image scaled to the terminal, two pixels rows per line
+func (t *Terminal) PrintPng(imgBytes []byte, w int, h int, x int, y int) {
+	img, _, err := image.Decode(bytes.NewReader(imgBytes))
+	if err != nil {
+		return
+	}
+
+	bounds := img.Bounds()
+	scale := bounds.Dx() / t.width
+	if rowScale := bounds.Dy() / (t.height * 2); rowScale > scale {
+		scale = rowScale
+	}
+	if scale < 1 {
+		scale = 1
+	}
+
+	isDark := func(px int, py int) bool {
+		r, g, b, _ := img.At(bounds.Min.X+px*scale, bounds.Min.Y+py*scale).RGBA()
+		return r+g+b < 3*0x8000
+	}
+
+	var buffer bytes.Buffer
+	columns := bounds.Dx() / scale
+	for row := 0; row < t.height && row*2*scale < bounds.Dy(); row++ {
+		fmt.Fprintf(&buffer, "\x1b[%d;%dH", row+1, (t.width-columns)/2+1)
+		for column := 0; column < columns; column++ {
+			top := isDark(column, row*2)
+			bottom := (row*2+1)*scale < bounds.Dy() && isDark(column, row*2+1)
+			switch {
+			case top && bottom:
+				buffer.WriteString("█")
+			case top:
+				buffer.WriteString("▀")
+			case bottom:
+				buffer.WriteString("▄")
+			default:
+				buffer.WriteString(" ")
+			}
+		}
+	}
+	t.out.Write(buffer.Bytes())
+}
+
+func (t *Terminal) Clear(flash bool) {
+	fmt.Fprint(t.out, "\x1b[0m\x1b[2J")
+}
+
+func (t *Terminal) Close() {
+	fmt.Fprint(t.out, "\x1b[0m\x1b[2J\x1b[H\x1b[?25h")
+}
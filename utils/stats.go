@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"sync"
+	"time"
+)
+
+const DayLayout = "2006-01-02"
+
+type WordCount struct {
+	Added   int `json:"added"`
+	Removed int `json:"removed"`
+}
+
+type SprintResult struct {
+	Start time.Time `json:"start"`
+	// in minutes
+	Duration int `json:"duration"`
+	// in words, for sprints to a word count
+	Target int `json:"target,omitempty"`
+	Words  int `json:"words"`
+}
+
+// Stats holds the words added and removed each day, by document name, and
+// the sprints done
+type Stats struct {
+	Days    map[string]map[string]WordCount `json:"days"`
+	Sprints []SprintResult                  `json:"sprints"`
+}
+
+func LoadStats(saveLocation string) Stats {
+	stats := Stats{}
+	content, err := os.ReadFile(path.Join(saveLocation, "stats.json"))
+	if err == nil {
+		json.Unmarshal(content, &stats)
+	}
+	if stats.Days == nil {
+		stats.Days = map[string]map[string]WordCount{}
+	}
+	return stats
+}
+
+// the documents save the words counted and the sprints are saved as they
+// end, whatever the view, so both go through the file under a lock
+var statsLock sync.Mutex
+
+func saveStats(stats Stats, saveLocation string) {
+	content, _ := json.Marshal(stats)
+	os.WriteFile(path.Join(saveLocation, "stats.json"), content, 0644)
+}
+
+// SaveWordCounts saves the words counted each day, keeping the sprints saved
+func SaveWordCounts(days map[string]map[string]WordCount, saveLocation string) {
+	statsLock.Lock()
+	defer statsLock.Unlock()
+	stats := LoadStats(saveLocation)
+	stats.Days = days
+	saveStats(stats, saveLocation)
+}
+
+// AddSprint saves the result of a sprint, keeping the words counted saved
+func AddSprint(result SprintResult, saveLocation string) {
+	statsLock.Lock()
+	defer statsLock.Unlock()
+	stats := LoadStats(saveLocation)
+	stats.Sprints = append(stats.Sprints, result)
+	saveStats(stats, saveLocation)
+}
+
+// Record counts words added to a document, or removed when negative
+func (s Stats) Record(day string, document string, words int) {
+	if words == 0 {
+		return
+	}
+	documents, ok := s.Days[day]
+	if !ok {
+		documents = map[string]WordCount{}
+		s.Days[day] = documents
+	}
+	count := documents[document]
+	if words > 0 {
+		count.Added += words
+	} else {
+		count.Removed -= words
+	}
+	documents[document] = count
+}
+
+// Written returns the words added less the words removed in a day
+func (s Stats) Written(day string) (words int) {
+	for _, count := range s.Days[day] {
+		words += count.Added - count.Removed
+	}
+	return
+}
+
+// Streak returns the count of days in a row that reached the goal, or that
+// had words written without a goal, up to today or yesterday as today is not
+// over
+func (s Stats) Streak(today time.Time, goal int) (streak int) {
+	day := today
+	if !s.reached(day.Format(DayLayout), goal) {
+		day = day.AddDate(0, 0, -1)
+	}
+	for s.reached(day.Format(DayLayout), goal) {
+		streak++
+		day = day.AddDate(0, 0, -1)
+	}
+	return
+}
+
+// BestStreak returns the longest count of days in a row that reached the goal
+func (s Stats) BestStreak(goal int) (best int) {
+	for day := range s.Days {
+		date, err := time.Parse(DayLayout, day)
+		if err != nil || !s.reached(day, goal) {
+			continue
+		}
+		// counted from the first day of each streak only
+		if s.reached(date.AddDate(0, 0, -1).Format(DayLayout), goal) {
+			continue
+		}
+		streak := 0
+		for s.reached(date.Format(DayLayout), goal) {
+			streak++
+			date = date.AddDate(0, 0, 1)
+		}
+		if streak > best {
+			best = streak
+		}
+	}
+	return
+}
+
+func (s Stats) reached(day string, goal int) bool {
+	written := s.Written(day)
+	if goal > 0 {
+		return written >= goal
+	}
+	return written > 0
+}
@@ -12,16 +12,37 @@ import (
 
 type Config struct {
 	LastOpenedDocument string `json:"lastOpenDocument"`
+	KeyboardLayout     string `json:"keyboardLayout"`
+	// in milliseconds and repeats per second, kernel repeat is used when unset
+	RepeatDelay int `json:"repeatDelay"`
+	RepeatRate  int `json:"repeatRate"`
+	// key combination to command name, applied over the default bindings
+	Keybindings map[string]string `json:"keybindings"`
+	// the status bar of the documents is shown unless hidden
+	HideStatusBar bool `json:"hideStatusBar"`
+	// words to write each day, no goal when 0
+	DailyGoal int `json:"dailyGoal"`
+	// the line of the cursor stays in the middle of the screen
+	Typewriter bool `json:"typewriter"`
+	// sentence or paragraph, the rest of the text is hidden
+	Focus string `json:"focus"`
+	// headings, emphasis, lists and quotes are shown as such
+	Markdown bool `json:"markdown"`
+	// paths of the documents that can only be written forward
+	DraftingDocuments map[string]bool `json:"draftingDocuments"`
 }
 
 func LoadConfig(saveLocation string) Config {
 	content, err := os.ReadFile(path.Join(saveLocation, "config.json"))
 
 	if err != nil {
+		// saved right away, or every load would pick a new document
 		id, _ := gonanoid.New()
-		return Config{
-			LastOpenedDocument: id + ".txt",
+		config := Config{
+			LastOpenedDocument: path.Join(saveLocation, id+".txt"),
 		}
+		SaveConfig(config, saveLocation)
+		return config
 	}
 
 	var config Config
@@ -44,19 +65,6 @@ func IsLetter(s string) bool {
 	return !strings.Contains(s, "KEY")
 }
 
-func InsertAt(text string, insert string, index int) string {
-	if index == LenString(text) {
-		return text + insert
-	}
-	runeText := []rune(text)
-	return string(append(runeText[:index], append([]rune(insert), runeText[index:]...)...))
-}
-
-func DeleteAt(text string, index int) string {
-	runeText := []rune(text)
-	return string(append(runeText[:index-1], runeText[index:]...))
-}
-
 func LenString(s string) int {
 	return utf8.RuneCountInString(s)
 }
@@ -0,0 +1,176 @@
+package views
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/olup/kobowriter/utils"
+)
+
+// gapBuffer holds the runes of a document around a gap placed where the last
+// edit happened, so that typing only moves the runes between two edits
+type gapBuffer struct {
+	runes    []rune
+	gapStart int
+	gapEnd   int
+}
+
+const minGap = 1024
+
+func newGapBuffer(text string) *gapBuffer {
+	runes := []rune(text)
+	b := &gapBuffer{
+		runes:    make([]rune, len(runes)+minGap),
+		gapStart: len(runes),
+		gapEnd:   len(runes) + minGap,
+	}
+	copy(b.runes, runes)
+	return b
+}
+
+func (b *gapBuffer) len() int {
+	return len(b.runes) - (b.gapEnd - b.gapStart)
+}
+
+func (b *gapBuffer) moveGap(index int) {
+	if index < b.gapStart {
+		moved := b.gapStart - index
+		copy(b.runes[b.gapEnd-moved:b.gapEnd], b.runes[index:b.gapStart])
+		b.gapStart -= moved
+		b.gapEnd -= moved
+	} else if index > b.gapStart {
+		moved := index - b.gapStart
+		copy(b.runes[b.gapStart:b.gapStart+moved], b.runes[b.gapEnd:b.gapEnd+moved])
+		b.gapStart += moved
+		b.gapEnd += moved
+	}
+}
+
+// grow makes the gap hold at least size runes, doubling the buffer
+func (b *gapBuffer) grow(size int) {
+	if b.gapEnd-b.gapStart >= size {
+		return
+	}
+	capacity := 2*len(b.runes) + size + minGap
+	runes := make([]rune, capacity)
+	copy(runes, b.runes[:b.gapStart])
+	after := len(b.runes) - b.gapEnd
+	copy(runes[capacity-after:], b.runes[b.gapEnd:])
+	b.runes = runes
+	b.gapEnd = capacity - after
+}
+
+// replace replaces count runes at index by text
+func (b *gapBuffer) replace(index int, count int, text string) {
+	b.moveGap(index)
+	b.gapEnd += count
+	b.grow(utf8.RuneCountInString(text))
+	for _, r := range text {
+		b.runes[b.gapStart] = r
+		b.gapStart++
+	}
+}
+
+func (b *gapBuffer) at(index int) rune {
+	if index >= b.gapStart {
+		index += b.gapEnd - b.gapStart
+	}
+	return b.runes[index]
+}
+
+// slice returns the runes from start included to end excluded
+func (b *gapBuffer) slice(start int, end int) string {
+	gap := b.gapEnd - b.gapStart
+	if end <= b.gapStart {
+		return string(b.runes[start:end])
+	}
+	if start >= b.gapStart {
+		return string(b.runes[start+gap : end+gap])
+	}
+	return string(b.runes[start:b.gapStart]) + string(b.runes[b.gapEnd:end+gap])
+}
+
+func (b *gapBuffer) String() string {
+	return string(b.runes[:b.gapStart]) + string(b.runes[b.gapEnd:])
+}
+
+// writeTo writes the runes on both sides of the gap in UTF-8, without building
+// the whole text
+func (b *gapBuffer) writeTo(w io.Writer) error {
+	writer := bufio.NewWriter(w)
+	for _, r := range b.runes[:b.gapStart] {
+		writer.WriteRune(r)
+	}
+	for _, r := range b.runes[b.gapEnd:] {
+		writer.WriteRune(r)
+	}
+	return writer.Flush()
+}
+
+// paragraphs are wrapped on their own, so an edit only wraps again the ones
+// it touches
+type paragraph struct {
+	// in runes, without the new line
+	length int
+	// count of wrapped lines
+	lines int
+	words int
+}
+
+func wrapParagraphs(text string, width int, markdown bool) (paragraphs []paragraph, lineCount []int, lineIndent []int) {
+	for _, line := range strings.Split(text, "\n") {
+		// in markdown, list items and quotes wrap under their text
+		indent, rest := 0, line
+		if markdown {
+			indent = hangingIndent(line, width)
+			if indent > 0 {
+				rest = string([]rune(line)[indent:])
+			}
+		}
+		wrapped := strings.Split(utils.WrapLine(rest, width-indent), "\n")
+		for i, wrappedLine := range wrapped {
+			// each line counts the space or new line it was wrapped at
+			count := utf8.RuneCountInString(wrappedLine) + 1
+			if i == 0 {
+				lineCount = append(lineCount, count+indent)
+				lineIndent = append(lineIndent, 0)
+			} else {
+				lineCount = append(lineCount, count)
+				lineIndent = append(lineIndent, indent)
+			}
+		}
+		paragraphs = append(paragraphs, paragraph{
+			length: utf8.RuneCountInString(line),
+			lines:  len(wrapped),
+			words:  countWords(line),
+		})
+	}
+	return
+}
+
+// the splices happen in place when the count does not change, as when typing
+// in a paragraph
+
+func spliceParagraphs(paragraphs []paragraph, start int, end int, inserted []paragraph) []paragraph {
+	if len(inserted) == end-start {
+		copy(paragraphs[start:end], inserted)
+		return paragraphs
+	}
+	result := make([]paragraph, 0, len(paragraphs)-(end-start)+len(inserted))
+	result = append(result, paragraphs[:start]...)
+	result = append(result, inserted...)
+	return append(result, paragraphs[end:]...)
+}
+
+func spliceInts(values []int, start int, end int, inserted []int) []int {
+	if len(inserted) == end-start {
+		copy(values[start:end], inserted)
+		return values
+	}
+	result := make([]int, 0, len(values)-(end-start)+len(inserted))
+	result = append(result, values[:start]...)
+	result = append(result, inserted...)
+	return append(result, values[end:]...)
+}
@@ -0,0 +1,115 @@
+package views
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/asaskevich/EventBus"
+	"github.com/olup/kobowriter/screener"
+	"github.com/olup/kobowriter/utils"
+)
+
+// the clipboard keeps the last cut or copied texts, most recent first, for as
+// long as the program runs so that text can be moved between documents
+const clipboardRingSize = 10
+
+type clipboardRing struct {
+	lock    sync.Mutex
+	entries []string
+	// pasted by the next document mounted, when picked from the menu
+	pending bool
+}
+
+var clipboard clipboardRing
+
+func (c *clipboardRing) push(text string) {
+	if text == "" {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	entries := []string{text}
+	for _, entry := range c.entries {
+		if entry != text && len(entries) < clipboardRingSize {
+			entries = append(entries, entry)
+		}
+	}
+	c.entries = entries
+}
+
+func (c *clipboardRing) top() (string, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if len(c.entries) == 0 {
+		return "", false
+	}
+	return c.entries[0], true
+}
+
+func (c *clipboardRing) list() []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]string{}, c.entries...)
+}
+
+// pick moves an entry to the top of the ring, to be pasted by the document
+func (c *clipboardRing) pick(text string) {
+	c.push(text)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.pending = true
+}
+
+func (c *clipboardRing) takePending() (string, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if !c.pending || len(c.entries) == 0 {
+		return "", false
+	}
+	c.pending = false
+	return c.entries[0], true
+}
+
+func (t *TextView) copySelection() {
+	clipboard.push(t.selectedText())
+}
+
+func (t *TextView) cutSelection() {
+	clipboard.push(t.selectedText())
+	t.deleteSelection()
+}
+
+func (t *TextView) paste() {
+	if text, ok := clipboard.top(); ok {
+		t.insert(editPaste, text)
+	}
+}
+
+func ClipboardMenu(screen *screener.Screen, bus EventBus.Bus) func() {
+	options := []Option{
+		{
+			label: "Back",
+			action: func() {
+				bus.Publish("ROUTING", "document")
+			},
+		},
+	}
+
+	for _, entry := range clipboard.list() {
+		entry := entry
+		label := strings.TrimSpace(strings.ReplaceAll(entry, "\n", " "))
+		if utils.LenString(label) > 30 {
+			label = string([]rune(label)[0:30]) + "..."
+		}
+		options = append(options, Option{
+			label: label,
+			action: func() {
+				clipboard.pick(entry)
+				bus.Publish("ROUTING", "document")
+			},
+		})
+	}
+
+	return createMenu("Clipboard", options)(screen, bus)
+}
@@ -1,8 +1,10 @@
 package views
 
 import (
+	"fmt"
 	"os"
 	"path"
+	"sync"
 	"time"
 
 	"github.com/asaskevich/EventBus"
@@ -12,87 +14,291 @@ import (
 	"github.com/olup/kobowriter/utils"
 )
 
-func Document(screen *screener.Screen, bus EventBus.Bus, documentPath string) func() {
+// SaveDelay is the pause in typing after which a document is saved
+const SaveDelay = 2 * time.Second
+
+const saveInterval = 30 * time.Second
+const statsInterval = time.Minute
+
+func Document(screen *screener.Screen, bus EventBus.Bus, saveLocation string, documentPath string) func() {
 	docContent := []byte("")
 	if documentPath != "" {
 		docContent, _ = os.ReadFile(documentPath)
 	}
+	config := utils.LoadConfig(saveLocation)
 	text := &TextView{
 		width:       int(screen.Width) - 4,
 		height:      int(screen.Height) - 2,
-		content:     "",
 		scroll:      0,
 		cursorIndex: 0,
+		markdown:    config.Markdown,
 	}
 
 	text.setContent(string(docContent))
+	if documentPath != "" {
+		text.journal, text.history = openJournal(documentPath, text.text())
+	}
 	text.setCursorIndex(utils.LenString(string(docContent)))
 
-	onEvent := func(e event.KeyEvent) {
-		linesToMove := 1
-		if e.IsCtrl {
-			linesToMove = text.height
+	// the words added and removed are counted for the daily goal, in memory
+	// and saved every statsInterval and when leaving
+	stats := utils.LoadStats(saveLocation)
+	countedWords := text.words
+	countsChanged := false
+	lastCountsSave := time.Now()
+	countWords := func() {
+		if documentPath != "" && text.words != countedWords {
+			stats.Record(time.Now().Format(utils.DayLayout), path.Base(documentPath), text.words-countedWords)
+			countsChanged = true
+		}
+		sprint.record(text.words - countedWords)
+		countedWords = text.words
+	}
+	saveCounts := func() {
+		if countsChanged {
+			utils.SaveWordCounts(stats.Days, saveLocation)
+			countsChanged = false
 		}
+		lastCountsSave = time.Now()
+	}
+	if index, ok := openAt.take(documentPath); ok {
+		text.setCursorIndex(index)
+	}
 
-		// if date combo
-		if e.IsChar {
-			text.setContent(utils.InsertAt(text.content, e.KeyChar, text.cursorIndex))
-			text.setCursorIndex(text.cursorIndex + 1)
-		} else {
-			// if is modifier key
-			switch e.KeyValue {
-			case "KEY_BACKSPACE":
-				text.setContent(utils.DeleteAt(text.content, text.cursorIndex))
-				text.setCursorIndex(text.cursorIndex - 1)
-			case "KEY_DEL":
-				if text.cursorIndex < utils.LenString(text.content) {
-					text.setContent(utils.DeleteAt(text.content, text.cursorIndex+1))
-				}
-			case "KEY_SPACE":
-				text.setContent(utils.InsertAt(text.content, " ", text.cursorIndex))
-				text.setCursorIndex(text.cursorIndex + 1)
-			case "KEY_ENTER":
-				text.setContent(utils.InsertAt(text.content, "\n", text.cursorIndex))
-				text.setCursorIndex(text.cursorIndex + 1)
-			case "KEY_RIGHT":
-				text.setCursorIndex(text.cursorIndex + 1)
-			case "KEY_LEFT":
-				text.setCursorIndex(text.cursorIndex - 1)
-			case "KEY_DOWN":
-				text.setCursorPos(Position{
-					x: text.cursorPos.x,
-					y: text.cursorPos.y + linesToMove,
-				})
-			case "KEY_UP":
-				text.setCursorPos(Position{
-					x: text.cursorPos.x,
-					y: text.cursorPos.y - linesToMove,
-				})
-			case "KEY_ESC":
-				bus.Publish("ROUTING", "menu")
-			case "KEY_F1":
-				text.setContent(utils.InsertAt(text.content, time.Now().Format("02/01/2006"), text.cursorIndex))
-				text.setCursorIndex(text.cursorIndex + 10)
-
-			case "KEY_F12":
-				screen.RefreshFlash()
+	// coming back from the clipboard menu
+	if pasted, ok := clipboard.takePending(); ok {
+		text.insert(editPaste, pasted)
+	}
+
+	// writing the whole document is slow in long ones, so it is not done on
+	// each key but once typing pauses, at least every saveInterval, when
+	// leaving and on the save command. The first draw saves to create new
+	// documents.
+	savedChanges := -1
+	lastKey, lastSave := time.Now(), time.Now()
+	save := func() {
+		savedChanges = text.changes
+		lastSave = time.Now()
+		if documentPath != "" {
+			text.save(documentPath)
+		}
+	}
+	saveChanges := func() {
+		if text.changes != savedChanges {
+			save()
+		}
+	}
+
+	find := &search{text: text}
+	showStatusBar := !config.HideStatusBar
+	text.typewriter = config.Typewriter
+	text.focus = config.Focus
+	text.updateScroll()
+
+	// writing goes on from the end in drafting mode
+	drafting := documentPath != "" && config.DraftingDocuments[documentPath]
+	if drafting {
+		text.setCursorIndex(text.length())
+	}
+
+	// the drafting mode and a sprint going on show even when the status bar is
+	// hidden
+	statusBar := func() string {
+		bar := sprint.status()
+		if drafting {
+			if bar != "" {
+				bar = "  " + bar
 			}
+			bar = "Drafting" + bar
 		}
+		if showStatusBar {
+			if bar != "" {
+				bar += "  "
+			}
+			bar += text.statusBar()
+			if config.DailyGoal > 0 {
+				bar += fmt.Sprintf("  %d/%d today", stats.Written(time.Now().Format(utils.DayLayout)), config.DailyGoal)
+			}
+		}
+		return bar
+	}
+	shownStatusBar := ""
 
+	var lock sync.Mutex
+	draw := func() {
+		countWords()
 		compiledMatrix := matrix.PasteMatrix(screen.GetOriginalMatrix(), text.renderMatrix(), 2, 1)
+		if find.active {
+			compiledMatrix = matrix.PasteMatrix(compiledMatrix, find.renderPrompt(screen.Width), 0, screen.Height-1)
+		}
+		shownStatusBar = ""
+		if !find.active {
+			shownStatusBar = statusBar()
+		}
+		screen.SetStatusBar(shownStatusBar)
 		screen.Print(compiledMatrix)
+		if savedChanges < 0 {
+			save()
+		}
+	}
+	redraw := &deferredRedraw{lock: &lock, draw: draw}
 
-		if documentPath != "" {
-			os.WriteFile(path.Join(documentPath), []byte(text.content), 0644)
+	// moves end the selection, unless they extend it
+	moving := func(move func()) func() {
+		return func() {
+			text.clearSelection()
+			move()
+		}
+	}
+	selecting := func(move func()) func() {
+		return func() {
+			text.extendSelection(move)
+		}
+	}
+
+	// see event.Commands for the list of commands that can be bound
+	commands := map[string]func(){
+		"open-menu": func() {
+			bus.Publish("ROUTING", "menu")
+		},
+		"insert-date": func() {
+			text.insert(editInsert, time.Now().Format("02/01/2006"))
+		},
+		"full-refresh": func() {
+			screen.RefreshFlash()
+		},
+		"save":                  save,
+		"move-left":             moving(func() { text.moveBy(-1) }),
+		"move-right":            moving(func() { text.moveBy(1) }),
+		"move-up":               moving(func() { text.moveLines(-1) }),
+		"move-down":             moving(func() { text.moveLines(1) }),
+		"page-up":               moving(func() { text.moveLines(-text.height) }),
+		"page-down":             moving(func() { text.moveLines(text.height) }),
+		"move-word-left":        moving(text.moveWordLeft),
+		"move-word-right":       moving(text.moveWordRight),
+		"line-start":            moving(text.moveLineStart),
+		"line-end":              moving(text.moveLineEnd),
+		"document-start":        moving(text.moveDocumentStart),
+		"document-end":          moving(text.moveDocumentEnd),
+		"paragraph-up":          moving(text.moveParagraphUp),
+		"paragraph-down":        moving(text.moveParagraphDown),
+		"select-left":           selecting(func() { text.moveBy(-1) }),
+		"select-right":          selecting(func() { text.moveBy(1) }),
+		"select-up":             selecting(func() { text.moveLines(-1) }),
+		"select-down":           selecting(func() { text.moveLines(1) }),
+		"select-word-left":      selecting(text.moveWordLeft),
+		"select-word-right":     selecting(text.moveWordRight),
+		"select-page-up":        selecting(func() { text.moveLines(-text.height) }),
+		"select-page-down":      selecting(func() { text.moveLines(text.height) }),
+		"select-line-start":     selecting(text.moveLineStart),
+		"select-line-end":       selecting(text.moveLineEnd),
+		"select-document-start": selecting(text.moveDocumentStart),
+		"select-document-end":   selecting(text.moveDocumentEnd),
+		"select-all":            text.selectAll,
+		"cut":                   text.cutSelection,
+		"copy":                  text.copySelection,
+		"paste":                 text.paste,
+		"open-clipboard": func() {
+			bus.Publish("ROUTING", "clipboard-menu")
+		},
+		"delete-backward":      text.deleteBackward,
+		"delete-forward":       text.deleteForward,
+		"delete-word-backward": text.deleteWordBackward,
+		"delete-word-forward":  text.deleteWordForward,
+		"delete-line-end":      text.deleteLineEnd,
+		"delete-paragraph":     text.deleteParagraph,
+		"newline":              text.newline,
+		"undo":                 text.undo,
+		"redo":                 text.redo,
+		"search": func() {
+			find.open(false)
+		},
+		"replace": func() {
+			find.open(true)
+		},
+		"toggle-status-bar": func() {
+			showStatusBar = !showStatusBar
+			config := utils.LoadConfig(saveLocation)
+			config.HideStatusBar = !showStatusBar
+			utils.SaveConfig(config, saveLocation)
+		},
+		"sprint-menu": func() {
+			bus.Publish("ROUTING", "sprint-menu")
+		},
+		"search-documents": func() {
+			bus.Publish("ROUTING", "search-documents")
+		},
+		"search-next":     find.next,
+		"search-previous": find.previous,
+	}
+
+	onEvent := func(e event.KeyEvent) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		lastKey = time.Now()
+		name := event.Command(e)
+		if find.active {
+			find.onEvent(e)
+		} else if command, ok := commands[name]; ok {
+			if !drafting || text.draftingAllows(name) {
+				command()
+			}
+		} else if e.IsChar && e.KeyChar != "" {
+			text.insert(editInsert, e.KeyChar)
+		} else if e.KeyValue == "KEY_SPACE" {
+			text.insert(editInsert, " ")
+		}
+
+		if e.IsRepeat {
+			redraw.schedule()
+		} else {
+			draw()
+		}
+	}
+
+	// the router follows the sprint on ticks, the screen being drawn again
+	// only when the status bar changed or the sprint ended
+	onSprint := func(message string) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		if message != "" || (!find.active && statusBar() != shownStatusBar) {
+			draw()
+		}
+		if message != "" {
+			screen.PrintAlert(message, 40)
+		}
+	}
+
+	onTick := func(now time.Time) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		if now.Sub(lastKey) >= SaveDelay || now.Sub(lastSave) >= saveInterval {
+			saveChanges()
+		}
+		if now.Sub(lastCountsSave) >= statsInterval {
+			saveCounts()
 		}
 	}
 
-	bus.SubscribeAsync("KEY", onEvent, false)
+	bus.SubscribeAsync("KEY", onEvent, true)
+	bus.SubscribeAsync("SPRINT", onSprint, true)
+	bus.SubscribeAsync("TICK", onTick, true)
 
 	// display
 	bus.Publish("KEY", event.KeyEvent{})
 
 	return func() {
+		lock.Lock()
+		redraw.stop()
+		saveChanges()
+		saveCounts()
+		screen.SetStatusBar("")
+		lock.Unlock()
 		bus.Unsubscribe("KEY", onEvent)
+		bus.Unsubscribe("SPRINT", onSprint)
+		bus.Unsubscribe("TICK", onTick)
 	}
 }
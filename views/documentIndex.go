@@ -0,0 +1,207 @@
+package views
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// the index of the words of every document is kept in this file of the save
+// location, so that only the documents holding the words searched are read
+const indexFile = "search-index.json"
+
+type indexedDocument struct {
+	ModTime int64    `json:"modTime"`
+	Size    int64    `json:"size"`
+	Words   []string `json:"words"`
+}
+
+type documentIndex struct {
+	saveLocation string
+	Documents    map[string]indexedDocument `json:"documents"`
+	// word to the names of the documents holding it
+	words map[string][]string
+}
+
+// a hit of a search, in runes of the document
+type documentHit struct {
+	path    string
+	title   string
+	index   int
+	snippet string
+}
+
+// loadIndex reads the index, then indexes again the documents that changed
+func loadIndex(saveLocation string) *documentIndex {
+	index := &documentIndex{saveLocation: saveLocation}
+	if content, err := os.ReadFile(path.Join(saveLocation, indexFile)); err == nil {
+		json.Unmarshal(content, index)
+	}
+	if index.Documents == nil {
+		index.Documents = map[string]indexedDocument{}
+	}
+
+	files, _ := os.ReadDir(saveLocation)
+	present := map[string]bool{}
+	changed := false
+	for _, file := range files {
+		if !strings.HasSuffix(file.Name(), ".txt") {
+			continue
+		}
+		present[file.Name()] = true
+		info, err := file.Info()
+		if err != nil {
+			continue
+		}
+		indexed, ok := index.Documents[file.Name()]
+		if ok && indexed.ModTime == info.ModTime().UnixNano() && indexed.Size == info.Size() {
+			continue
+		}
+		content, err := os.ReadFile(path.Join(saveLocation, file.Name()))
+		if err != nil {
+			continue
+		}
+		index.Documents[file.Name()] = indexedDocument{
+			ModTime: info.ModTime().UnixNano(),
+			Size:    info.Size(),
+			Words:   indexWords(string(content)),
+		}
+		changed = true
+	}
+	for name := range index.Documents {
+		if !present[name] {
+			delete(index.Documents, name)
+			changed = true
+		}
+	}
+
+	if changed {
+		content, _ := json.Marshal(index)
+		os.WriteFile(path.Join(saveLocation, indexFile), content, 0644)
+	}
+
+	index.words = map[string][]string{}
+	for name, document := range index.Documents {
+		for _, word := range document.Words {
+			index.words[word] = append(index.words[word], name)
+		}
+	}
+	return index
+}
+
+// indexWords returns the distinct words of a text, in lower case
+func indexWords(text string) []string {
+	seen := map[string]bool{}
+	words := []string{}
+	for _, word := range strings.FieldsFunc(foldCase(text), isWordSeparator) {
+		if !seen[word] {
+			seen[word] = true
+			words = append(words, word)
+		}
+	}
+	sort.Strings(words)
+	return words
+}
+
+func isWordSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+}
+
+// foldCase lowers the case of a text, keeping its count of runes
+func foldCase(text string) string {
+	return strings.Map(unicode.ToLower, text)
+}
+
+// candidates returns the documents holding every word of the query, or words
+// holding them as they may still be typed, the recent documents first
+func (index *documentIndex) candidates(query string) []string {
+	names := map[string]int{}
+	terms := strings.FieldsFunc(foldCase(query), isWordSeparator)
+	for _, term := range terms {
+		holding := map[string]bool{}
+		for word, documents := range index.words {
+			if strings.Contains(word, term) {
+				for _, name := range documents {
+					holding[name] = true
+				}
+			}
+		}
+		for name := range holding {
+			names[name]++
+		}
+	}
+
+	candidates := []string{}
+	for name, count := range names {
+		if count == len(terms) {
+			candidates = append(candidates, name)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return index.Documents[candidates[i]].ModTime > index.Documents[candidates[j]].ModTime
+	})
+	return candidates
+}
+
+// search returns up to max hits of the query, ignoring case
+func (index *documentIndex) search(query string, max int) (hits []documentHit) {
+	folded := foldCase(query)
+	if strings.TrimSpace(folded) == "" {
+		return
+	}
+
+	for _, name := range index.candidates(query) {
+		documentPath := path.Join(index.saveLocation, name)
+		content, err := os.ReadFile(documentPath)
+		if err != nil {
+			continue
+		}
+		text := string(content)
+		runeText := []rune(text)
+		foldedText := foldCase(text)
+		title := strings.Split(text, "\n")[0]
+
+		offset, runes := 0, 0
+		for len(hits) < max {
+			found := strings.Index(foldedText[offset:], folded)
+			if found < 0 {
+				break
+			}
+			runes += utf8.RuneCountInString(foldedText[offset : offset+found])
+			offset += found
+			hits = append(hits, documentHit{
+				path:    documentPath,
+				title:   title,
+				index:   runes,
+				snippet: snippet(runeText, runes),
+			})
+			runes += utf8.RuneCountInString(folded)
+			offset += len(folded)
+		}
+	}
+	return
+}
+
+// snippet returns the line of a rune index, from a few words before it
+func snippet(text []rune, index int) string {
+	start, end := index, index
+	for start > 0 && text[start-1] != '\n' && index-start < 20 {
+		start--
+	}
+	for end < len(text) && text[end] != '\n' {
+		end++
+	}
+
+	// cut after a space when the line starts before
+	if start > 0 && text[start-1] != '\n' {
+		for start < index && text[start] != ' ' {
+			start++
+		}
+		return "..." + strings.TrimLeft(string(text[start:end]), " ")
+	}
+	return string(text[start:end])
+}
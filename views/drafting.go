@@ -0,0 +1,44 @@
+package views
+
+import "unicode"
+
+// in drafting mode the text already written cannot be edited, only the word
+// being typed at the end of the document, so these commands are the only ones
+// left with the ones moving back in that word
+var draftingCommands = map[string]bool{
+	"open-menu":         true,
+	"insert-date":       true,
+	"full-refresh":      true,
+	"save":              true,
+	"toggle-status-bar": true,
+	"sprint-menu":       true,
+	"search-documents":  true,
+	"move-right":        true,
+	"move-down":         true,
+	"page-down":         true,
+	"move-word-right":   true,
+	"line-end":          true,
+	"document-end":      true,
+	"paragraph-down":    true,
+	"delete-forward":    true,
+	"paste":             true,
+	"open-clipboard":    true,
+	"newline":           true,
+}
+
+func (t *TextView) draftingAllows(command string) bool {
+	switch command {
+	case "move-left", "delete-backward", "delete-word-backward":
+		return t.cursorIndex > t.draftStart()
+	}
+	return draftingCommands[command]
+}
+
+// draftStart returns the start of the word at the end of the text
+func (t *TextView) draftStart() int {
+	index := t.length()
+	for index > 0 && !unicode.IsSpace(t.buffer.at(index-1)) {
+		index--
+	}
+	return index
+}
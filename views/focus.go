@@ -0,0 +1,68 @@
+package views
+
+import (
+	"unicode"
+
+	"github.com/olup/kobowriter/matrix"
+)
+
+// in focus mode, only the sentence or the paragraph of the cursor is shown
+const (
+	focusOff       = ""
+	focusSentence  = "sentence"
+	focusParagraph = "paragraph"
+)
+
+var focusModes = []string{focusOff, focusSentence, focusParagraph}
+
+// renderFocus blanks the cells out of focus
+func (t *TextView) renderFocus(textMatrix matrix.Matrix) {
+	var start, end int
+	switch t.focus {
+	case focusSentence:
+		start, end = t.sentenceBounds(t.cursorIndex)
+	case focusParagraph:
+		start, end = t.paragraphBounds(t.cursorIndex)
+	default:
+		return
+	}
+
+	t.eachCell(textMatrix, func(cell *matrix.MatrixElement, index int) {
+		if index < start || index >= end {
+			cell.Content = ' '
+			cell.IsInverted = false
+		}
+	})
+}
+
+func isSentenceEnd(r rune) bool {
+	return r == '.' || r == '!' || r == '?' || r == '…'
+}
+
+// sentenceBounds returns the sentence holding index, in its paragraph. The
+// sentence just ended before the cursor is still the present one.
+func (t *TextView) sentenceBounds(index int) (start int, end int) {
+	paragraphStart, paragraphEnd := t.paragraphBounds(index)
+
+	from := index
+	if from > paragraphStart && isSentenceEnd(t.buffer.at(from-1)) {
+		from--
+	}
+
+	start = from
+	for start > paragraphStart && !isSentenceEnd(t.buffer.at(start-1)) {
+		start--
+	}
+	for start < from && unicode.IsSpace(t.buffer.at(start)) {
+		start++
+	}
+
+	end = from
+	for end < paragraphEnd && !isSentenceEnd(t.buffer.at(end)) {
+		end++
+	}
+	if end < paragraphEnd {
+		end++
+	}
+	return
+}
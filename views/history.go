@@ -0,0 +1,145 @@
+package views
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// the kobo has little memory, so only the most recent edits are kept
+const maxHistorySteps = 500
+const maxHistoryBytes = 256 * 1024
+
+type editKind int
+
+const (
+	editInsert editKind = iota
+	editDelete
+	editPaste
+	editReplace
+)
+
+// edit replaces deleted by inserted at a rune index of the content
+type edit struct {
+	kind         editKind
+	index        int
+	deleted      string
+	inserted     string
+	cursorBefore int
+	cursorAfter  int
+}
+
+func (e edit) size() int {
+	return len(e.deleted) + len(e.inserted)
+}
+
+// a step is undone or redone at once, consecutive typing is grouped by word
+type step []edit
+
+type history struct {
+	undos []step
+	redos []step
+	bytes int
+	// the last step is still being typed and can take more edits
+	open bool
+}
+
+// record adds an edit to the history, and tells if it joined the last step
+func (h *history) record(e edit) (grouped bool) {
+	grouped = h.open && len(h.undos) > 0 && canGroup(h.undos[len(h.undos)-1], e)
+	h.push(e, grouped)
+	h.open = isTyping(e)
+	return
+}
+
+func (h *history) push(e edit, grouped bool) {
+	h.redos = nil
+
+	if grouped && len(h.undos) > 0 {
+		last := len(h.undos) - 1
+		h.undos[last] = append(h.undos[last], e)
+	} else {
+		h.undos = append(h.undos, step{e})
+	}
+	h.bytes += e.size()
+
+	h.trim()
+}
+
+// seal ends the present step, the next edit starting a new one
+func (h *history) seal() {
+	h.open = false
+}
+
+func (h *history) trim() {
+	for len(h.undos) > 1 && (len(h.undos) > maxHistorySteps || h.bytes > maxHistoryBytes) {
+		for _, e := range h.undos[0] {
+			h.bytes -= e.size()
+		}
+		h.undos = h.undos[1:]
+	}
+}
+
+func (h *history) popUndo() (step, bool) {
+	if len(h.undos) == 0 {
+		return nil, false
+	}
+	s := h.undos[len(h.undos)-1]
+	h.undos = h.undos[:len(h.undos)-1]
+	h.redos = append(h.redos, s)
+	h.open = false
+	return s, true
+}
+
+func (h *history) popRedo() (step, bool) {
+	if len(h.redos) == 0 {
+		return nil, false
+	}
+	s := h.redos[len(h.redos)-1]
+	h.redos = h.redos[:len(h.redos)-1]
+	h.undos = append(h.undos, s)
+	h.open = false
+	return s, true
+}
+
+// typing inserts or deletes a single character
+func isTyping(e edit) bool {
+	switch e.kind {
+	case editInsert:
+		return e.deleted == "" && utf8.RuneCountInString(e.inserted) == 1
+	case editDelete:
+		return e.inserted == "" && utf8.RuneCountInString(e.deleted) == 1
+	}
+	return false
+}
+
+func canGroup(s step, e edit) bool {
+	previous := s[len(s)-1]
+	if !isTyping(e) || previous.kind != e.kind {
+		return false
+	}
+
+	var previousChar, char rune
+	backward := false
+	switch e.kind {
+	case editInsert:
+		if e.index != previous.index+1 {
+			return false
+		}
+		previousChar, _ = utf8.DecodeRuneInString(previous.inserted)
+		char, _ = utf8.DecodeRuneInString(e.inserted)
+	case editDelete:
+		// backspace goes back one character, delete stays in place
+		backward = e.index == previous.index-1
+		if !backward && e.index != previous.index {
+			return false
+		}
+		previousChar, _ = utf8.DecodeRuneInString(previous.deleted)
+		char, _ = utf8.DecodeRuneInString(e.deleted)
+	}
+
+	// a step is a word with the spaces following it
+	if backward {
+		return !(!unicode.IsSpace(previousChar) && unicode.IsSpace(char))
+	}
+	return !(unicode.IsSpace(previousChar) && !unicode.IsSpace(char))
+}
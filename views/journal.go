@@ -0,0 +1,213 @@
+package views
+
+import (
+	"bufio"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// the journal is compacted to the history kept in memory past this size
+const maxJournalBytes = 1024 * 1024
+
+// journal appends the history of a document to a file next to it, so edits
+// can be undone after a restart. Each line is an operation followed by the
+// checksum of the content once applied, or - when unknown:
+//
+//	N <crc> <kind> <index> <cursor before> <cursor after> <deleted> <inserted>
+//	G ... same as N, the edit joining the last step
+//	U <crc>    undo
+//	R <crc>    redo
+//	C <crc>    checksum written when the document is saved or compacted
+//
+// Checksumming the whole content on each key would be slow in long documents,
+// so the operations are written with - and a checksum follows when saving.
+// The journal is only replayed when its last line has a checksum matching the
+// document, an edit made elsewhere, or not saved, making it useless.
+type journal struct {
+	path string
+	size int64
+	// operations were written since the last checksum
+	pending bool
+}
+
+func journalPath(documentPath string) string {
+	return strings.TrimSuffix(documentPath, path.Ext(documentPath)) + ".history"
+}
+
+func checksum(content string) string {
+	return formatChecksum(crc32.ChecksumIEEE([]byte(content)))
+}
+
+func formatChecksum(crc uint32) string {
+	return fmt.Sprintf("%08x", crc)
+}
+
+// openJournal returns the journal of a document with the history it holds
+func openJournal(documentPath string, content string) (*journal, history) {
+	j := &journal{path: journalPath(documentPath)}
+	h := history{}
+
+	file, err := os.Open(j.path)
+	if err != nil {
+		return j, h
+	}
+	defer file.Close()
+
+	lastChecksum := ""
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), maxJournalBytes)
+	for scanner.Scan() {
+		line := scanner.Text()
+		j.size += int64(len(line)) + 1
+
+		fields := strings.SplitN(line, " ", 3)
+		if len(fields) < 2 {
+			continue
+		}
+		lastChecksum = fields[1]
+
+		switch fields[0] {
+		case "N", "G":
+			var e edit
+			var crc string
+			_, err := fmt.Sscanf(line[2:], "%s %d %d %d %d %q %q", &crc, &e.kind, &e.index, &e.cursorBefore, &e.cursorAfter, &e.deleted, &e.inserted)
+			if err != nil {
+				return j.reset(), history{}
+			}
+			h.push(e, fields[0] == "G")
+		case "U":
+			h.popUndo()
+		case "R":
+			h.popRedo()
+		}
+	}
+
+	if scanner.Err() != nil || lastChecksum != checksum(content) {
+		return j.reset(), history{}
+	}
+	return j, h
+}
+
+func (j *journal) reset() *journal {
+	os.Remove(j.path)
+	j.size = 0
+	return j
+}
+
+func (j *journal) append(lines string) {
+	file, err := os.OpenFile(j.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	n, _ := file.WriteString(lines)
+	j.size += int64(n)
+}
+
+func editLine(e edit, grouped bool, crc string) string {
+	op := "N"
+	if grouped {
+		op = "G"
+	}
+	return fmt.Sprintf("%s %s %d %d %d %d %q %q\n", op, crc, e.kind, e.index, e.cursorBefore, e.cursorAfter, e.deleted, e.inserted)
+}
+
+// recordEdit records an edit, the content only being asked for when the
+// journal is compacted
+func (j *journal) recordEdit(e edit, grouped bool, content func() string, h *history) {
+	if j == nil {
+		return
+	}
+	j.append(editLine(e, grouped, "-"))
+	j.pending = true
+	j.compactIfNeeded(h, content)
+}
+
+// recordStep records the edits of a step
+func (j *journal) recordStep(edits []edit, content func() string, h *history) {
+	var lines strings.Builder
+	for i, e := range edits {
+		lines.WriteString(editLine(e, i > 0, "-"))
+	}
+	j.append(lines.String())
+	j.pending = true
+	j.compactIfNeeded(h, content)
+}
+
+func (j *journal) recordUndo() {
+	if j != nil {
+		j.append("U -\n")
+		j.pending = true
+	}
+}
+
+func (j *journal) recordRedo() {
+	if j != nil {
+		j.append("R -\n")
+		j.pending = true
+	}
+}
+
+// checkpoint writes the checksum of the content saved, when operations were
+// written since the last one
+func (j *journal) checkpoint(crc uint32) {
+	if j != nil && j.pending {
+		j.append("C " + formatChecksum(crc) + "\n")
+		j.pending = false
+	}
+}
+
+// save writes the document and checksums it for the journal on the way
+func (t *TextView) save(documentPath string) error {
+	file, err := os.Create(documentPath)
+	if err != nil {
+		return err
+	}
+	crc := crc32.NewIEEE()
+	err = t.buffer.writeTo(io.MultiWriter(file, crc))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		t.journal.checkpoint(crc.Sum32())
+	}
+	return err
+}
+
+// compactIfNeeded rewrites the journal with the history kept in memory: the
+// undo steps, then the redo steps as edits undone right away
+func (j *journal) compactIfNeeded(h *history, content func() string) {
+	if j.size <= maxJournalBytes {
+		return
+	}
+
+	var lines strings.Builder
+	for _, s := range h.undos {
+		for i, e := range s {
+			lines.WriteString(editLine(e, i > 0, "-"))
+		}
+	}
+	for i := len(h.redos) - 1; i >= 0; i-- {
+		for k, e := range h.redos[i] {
+			lines.WriteString(editLine(e, k > 0, "-"))
+		}
+	}
+	for range h.redos {
+		lines.WriteString("U -\n")
+	}
+	lines.WriteString("C " + checksum(content()) + "\n")
+
+	temporaryPath := j.path + ".tmp"
+	if err := os.WriteFile(temporaryPath, []byte(lines.String()), 0644); err != nil {
+		return
+	}
+	if err := os.Rename(temporaryPath, j.path); err != nil {
+		return
+	}
+	j.size = int64(lines.Len())
+	j.pending = false
+}
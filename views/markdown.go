@@ -0,0 +1,137 @@
+package views
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/olup/kobowriter/matrix"
+	"github.com/olup/kobowriter/utils"
+)
+
+// in markdown mode headings and emphasis are shown inverted, list items and
+// quotes wrap under their text and lists go on at each new line, the text
+// itself being saved as written
+
+// the quotes, bullet or number starting a line, all optional
+var blockMarker = regexp.MustCompile(`^[ \t]*(> ?)*([-*+] |\d{1,9}[.)] )?`)
+var numberMarker = regexp.MustCompile(`(\d{1,9})([.)] )$`)
+var heading = regexp.MustCompile(`^#{1,6}( |$)`)
+var emphasis = regexp.MustCompile(`\*\*[^*\s](?:[^*]*[^*\s])?\*\*|__[^_\s](?:[^_]*[^_\s])?__|\*[^*\s](?:[^*]*[^*\s])?\*|_[^_\s](?:[^_]*[^_\s])?_|` + "`[^`]+`")
+
+// hangingIndent returns the columns the lines of a list item or quote are
+// indented by, none when it would leave too little room for the text
+func hangingIndent(line string, width int) int {
+	indent := utf8.RuneCountInString(blockMarker.FindString(line))
+	if indent > width/2 {
+		return 0
+	}
+	return indent
+}
+
+// nextMarker returns the marker of the line after one starting with marker,
+// the number going up in ordered lists
+func nextMarker(marker string) string {
+	match := numberMarker.FindStringSubmatchIndex(marker)
+	if match == nil {
+		return marker
+	}
+	number, _ := strconv.Atoi(marker[match[2]:match[3]])
+	return marker[:match[2]] + strconv.Itoa(number+1) + marker[match[4]:]
+}
+
+// markdownStyles returns the ranges of a paragraph starting at offset shown
+// inverted: the whole of a heading, or the text between emphasis markers
+func markdownStyles(text string, offset int) (ranges [][2]int) {
+	if heading.MatchString(text) {
+		return [][2]int{{offset, offset + utf8.RuneCountInString(text)}}
+	}
+
+	for _, match := range emphasis.FindAllStringIndex(text, -1) {
+		marker := 1
+		if strings.HasPrefix(text[match[0]:], "**") || strings.HasPrefix(text[match[0]:], "__") {
+			marker = 2
+		}
+		// underscores inside words, as in snake_case, are no emphasis
+		if text[match[0]] == '_' && (isWordRune(lastRune(text[:match[0]])) || isWordRune(firstRune(text[match[1]:]))) {
+			continue
+		}
+		start := offset + utf8.RuneCountInString(text[:match[0]])
+		end := start + utf8.RuneCountInString(text[match[0]:match[1]])
+		ranges = append(ranges, [2]int{start + marker, end - marker})
+	}
+	return
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func firstRune(text string) rune {
+	r, _ := utf8.DecodeRuneInString(text)
+	return r
+}
+
+func lastRune(text string) rune {
+	r, _ := utf8.DecodeLastRuneInString(text)
+	return r
+}
+
+// renderMarkdown inverts the headings and emphasis of the paragraphs in view
+func (t *TextView) renderMarkdown(textMatrix matrix.Matrix) {
+	if !t.markdown {
+		return
+	}
+
+	var ranges [][2]int
+	start, line := 0, 0
+	for _, p := range t.paragraphs {
+		if line >= t.scroll+len(textMatrix) {
+			break
+		}
+		if line+p.lines > t.scroll {
+			ranges = append(ranges, markdownStyles(t.buffer.slice(start, start+p.length), start)...)
+		}
+		start += p.length + 1
+		line += p.lines
+	}
+	if len(ranges) == 0 {
+		return
+	}
+
+	t.eachCell(textMatrix, func(cell *matrix.MatrixElement, index int) {
+		for _, r := range ranges {
+			if index >= r[0] && index < r[1] {
+				cell.IsInverted = true
+				break
+			}
+		}
+	})
+}
+
+// newline goes on with the list or quote of the paragraph in markdown mode,
+// an item left empty ending it instead
+func (t *TextView) newline() {
+	if !t.markdown {
+		t.insert(editInsert, "\n")
+		return
+	}
+	if _, _, ok := t.selection(); ok {
+		t.insert(editInsert, "\n")
+		return
+	}
+
+	start, end := t.paragraphBounds(t.cursorIndex)
+	marker := blockMarker.FindString(t.buffer.slice(start, t.cursorIndex))
+	length := utils.LenString(marker)
+	switch {
+	case length == 0:
+		t.insert(editInsert, "\n")
+	case start+length == end:
+		t.edit(editDelete, start, length, "")
+	default:
+		t.insert(editInsert, "\n"+nextMarker(marker))
+	}
+}
@@ -1,6 +1,7 @@
 package views
 
 import (
+	"fmt"
 	"os"
 	"os/exec"
 	"path"
@@ -25,14 +26,16 @@ func createMenu(title string, options []Option) func(screen *screener.Screen, bu
 		selected := 0
 		onKey := func(e event.KeyEvent) {
 
-			if e.KeyValue == "KEY_UP" && selected > 0 {
-				selected--
-			}
-			if e.KeyValue == "KEY_DOWN" && selected < len(options)-1 {
-				selected++
-			}
-
-			if e.KeyValue == "KEY_ENTER" {
+			switch event.MenuCommand(e) {
+			case "menu-up":
+				if selected > 0 {
+					selected--
+				}
+			case "menu-down":
+				if selected < len(options)-1 {
+					selected++
+				}
+			case "menu-select":
 				options[selected].action()
 			}
 
@@ -54,7 +57,7 @@ func createMenu(title string, options []Option) func(screen *screener.Screen, bu
 			screen.Print(matrixx)
 		}
 
-		bus.SubscribeAsync("KEY", onKey, false)
+		bus.SubscribeAsync("KEY", onKey, true)
 
 		// display
 		bus.Publish("KEY", event.KeyEvent{})
@@ -85,6 +88,12 @@ func MainMenu(screen *screener.Screen, bus EventBus.Bus, saveLocation string) fu
 				bus.Publish("ROUTING", "file-menu")
 			},
 		},
+		{
+			label: "Search Documents",
+			action: func() {
+				bus.Publish("ROUTING", "search-documents")
+			},
+		},
 		{
 			label: "New Document",
 			action: func() {
@@ -97,6 +106,35 @@ func MainMenu(screen *screener.Screen, bus EventBus.Bus, saveLocation string) fu
 				bus.Publish("ROUTING", "document")
 			},
 		},
+		{
+			label: "Writing Sprint",
+			action: func() {
+				bus.Publish("ROUTING", "sprint-menu")
+			},
+		},
+		{
+			label: "Stats",
+			action: func() {
+				bus.Publish("ROUTING", "stats")
+			},
+		},
+		{
+			label: draftingLabel(saveLocation),
+			action: func() {
+				config := utils.LoadConfig(saveLocation)
+				if config.DraftingDocuments == nil {
+					config.DraftingDocuments = map[string]bool{}
+				}
+				if config.DraftingDocuments[config.LastOpenedDocument] {
+					delete(config.DraftingDocuments, config.LastOpenedDocument)
+				} else {
+					config.DraftingDocuments[config.LastOpenedDocument] = true
+				}
+				utils.SaveConfig(config, saveLocation)
+
+				bus.Publish("ROUTING", "document")
+			},
+		},
 		{
 			label: "Settings",
 			action: func() {
@@ -165,14 +203,31 @@ func FileMenu(screen *screener.Screen, bus EventBus.Bus, saveLocation string) fu
 }
 
 func SettingsMenu(screen *screener.Screen, bus EventBus.Bus, saveLocation string) func() {
-	options := []Option{
-		{
+	var options []Option
+
+	// setting adds an option changing the config, its label following it
+	setting := func(label func(config utils.Config) string, change func(config *utils.Config)) {
+		index := len(options)
+		options = append(options, Option{
+			label: label(utils.LoadConfig(saveLocation)),
+			action: func() {
+				config := utils.LoadConfig(saveLocation)
+				change(&config)
+				utils.SaveConfig(config, saveLocation)
+
+				options[index].label = label(config)
+			},
+		})
+	}
+
+	options = append(options,
+		Option{
 			label: "Back",
 			action: func() {
 				bus.Publish("ROUTING", "menu")
 			},
 		},
-		{
+		Option{
 			label: "Toggle light",
 			action: func() {
 				lightPath := "/sys/class/backlight/mxc_msp430_fl.0/brightness"
@@ -189,7 +244,101 @@ func SettingsMenu(screen *screener.Screen, bus EventBus.Bus, saveLocation string
 				os.WriteFile(lightPath, []byte(light), os.ModePerm)
 			},
 		},
+	)
+	setting(func(config utils.Config) string {
+		return "Keyboard: " + event.CurrentLayout().Label
+	}, func(config *utils.Config) {
+		layout := event.NextLayout(event.CurrentLayout().Name)
+		event.SetLayout(layout.Name)
+		config.KeyboardLayout = layout.Name
+	})
+	setting(func(config utils.Config) string {
+		return statusBarLabel(config.HideStatusBar)
+	}, func(config *utils.Config) {
+		config.HideStatusBar = !config.HideStatusBar
+	})
+	setting(func(config utils.Config) string {
+		return dailyGoalLabel(config.DailyGoal)
+	}, func(config *utils.Config) {
+		config.DailyGoal = nextDailyGoal(config.DailyGoal)
+	})
+	setting(func(config utils.Config) string {
+		return typewriterLabel(config.Typewriter)
+	}, func(config *utils.Config) {
+		config.Typewriter = !config.Typewriter
+	})
+	setting(func(config utils.Config) string {
+		return focusLabel(config.Focus)
+	}, func(config *utils.Config) {
+		config.Focus = nextFocusMode(config.Focus)
+	})
+	setting(func(config utils.Config) string {
+		return markdownLabel(config.Markdown)
+	}, func(config *utils.Config) {
+		config.Markdown = !config.Markdown
+	})
+
+	return createMenu("Settings", options)(screen, bus)
+}
+
+func statusBarLabel(hidden bool) string {
+	if hidden {
+		return "Status bar: hidden"
 	}
+	return "Status bar: shown"
+}
 
-	return createMenu("Open File", options)(screen, bus)
+func dailyGoalLabel(goal int) string {
+	if goal == 0 {
+		return "Daily goal: none"
+	}
+	return fmt.Sprintf("Daily goal: %d words", goal)
+}
+
+func nextDailyGoal(goal int) int {
+	for _, next := range dailyGoals {
+		if next > goal {
+			return next
+		}
+	}
+	return dailyGoals[0]
+}
+
+// draftingLabel tells the drafting mode of the opened document
+func draftingLabel(saveLocation string) string {
+	config := utils.LoadConfig(saveLocation)
+	if config.DraftingDocuments[config.LastOpenedDocument] {
+		return "Drafting mode: on"
+	}
+	return "Drafting mode: off"
+}
+
+func typewriterLabel(on bool) string {
+	if on {
+		return "Typewriter scrolling: on"
+	}
+	return "Typewriter scrolling: off"
+}
+
+func markdownLabel(on bool) string {
+	if on {
+		return "Markdown: on"
+	}
+	return "Markdown: off"
+}
+
+func focusLabel(focus string) string {
+	if focus == focusOff {
+		return "Focus: off"
+	}
+	return "Focus: " + focus
+}
+
+func nextFocusMode(focus string) string {
+	for i, mode := range focusModes {
+		if mode == focus {
+			return focusModes[(i+1)%len(focusModes)]
+		}
+	}
+	return focusOff
 }
@@ -16,7 +16,7 @@ func Qr(screen *screener.Screen, bus EventBus.Bus, saveLocation string) func() {
 		bus.Publish("ROUTING", "menu")
 	}
 
-	bus.SubscribeAsync("KEY", onKey, false)
+	bus.SubscribeAsync("KEY", onKey, true)
 
 	// Display QR on mount
 	screen.Clear()
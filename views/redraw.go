@@ -0,0 +1,38 @@
+package views
+
+import (
+	"sync"
+	"time"
+)
+
+// held keys repeat faster than the e-ink screen refreshes, so the redraws
+// of repeated events are merged into a single one
+const repeatRedrawDelay = 150 * time.Millisecond
+
+type deferredRedraw struct {
+	lock    *sync.Mutex
+	draw    func()
+	pending bool
+	stopped bool
+}
+
+// schedule must be called with the lock held
+func (d *deferredRedraw) schedule() {
+	if d.pending || d.stopped {
+		return
+	}
+	d.pending = true
+	time.AfterFunc(repeatRedrawDelay, func() {
+		d.lock.Lock()
+		defer d.lock.Unlock()
+		d.pending = false
+		if !d.stopped {
+			d.draw()
+		}
+	})
+}
+
+// stop must be called with the lock held
+func (d *deferredRedraw) stop() {
+	d.stopped = true
+}
@@ -0,0 +1,72 @@
+package views
+
+import (
+	"sync"
+	"time"
+
+	"github.com/asaskevich/EventBus"
+	"github.com/olup/kobowriter/screener"
+	"github.com/olup/kobowriter/utils"
+)
+
+// Router mounts the view named by ROUTING events, unmounting the previous one
+func Router(screen *screener.Screen, bus EventBus.Bus, saveLocation string) {
+	var unmount func()
+	var lock sync.Mutex
+	showsDocument := false
+	bus.SubscribeAsync("ROUTING", func(routeName string) {
+		if unmount != nil {
+			unmount()
+		}
+
+		document := false
+		switch routeName {
+		case "document":
+			document = true
+			config := utils.LoadConfig(saveLocation)
+			unmount = Document(screen, bus, saveLocation, config.LastOpenedDocument)
+		case "menu":
+			unmount = MainMenu(screen, bus, saveLocation)
+		case "file-menu":
+			unmount = FileMenu(screen, bus, saveLocation)
+		case "settings-menu":
+			unmount = SettingsMenu(screen, bus, saveLocation)
+		case "sprint-menu":
+			unmount = SprintMenu(screen, bus)
+		case "stats":
+			unmount = Stats(screen, bus, saveLocation)
+		case "search-documents":
+			unmount = SearchDocuments(screen, bus, saveLocation)
+		case "clipboard-menu":
+			unmount = ClipboardMenu(screen, bus)
+		case "qr":
+			unmount = Qr(screen, bus, saveLocation)
+
+		default:
+			document = true
+			unmount = Document(screen, bus, saveLocation, "")
+		}
+
+		lock.Lock()
+		showsDocument = document
+		lock.Unlock()
+	}, true)
+
+	// sprints end whatever the view, a document being told for it to draw its
+	// status bar before the alert
+	bus.SubscribeAsync("TICK", func(now time.Time) {
+		result, message := sprint.update(now)
+		if result != nil {
+			utils.AddSprint(*result, saveLocation)
+		}
+
+		lock.Lock()
+		document := showsDocument
+		lock.Unlock()
+		if document {
+			bus.Publish("SPRINT", message)
+		} else if message != "" {
+			screen.PrintAlert(message, 40)
+		}
+	}, true)
+}
@@ -0,0 +1,283 @@
+package views
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/olup/kobowriter/event"
+	"github.com/olup/kobowriter/matrix"
+	"github.com/olup/kobowriter/utils"
+)
+
+// search finds a query in the text view as it is typed in a prompt, and
+// replaces the matches in replace mode
+type search struct {
+	text      *TextView
+	active    bool
+	replacing bool
+	// the replacement is typed instead of the query
+	typingReplacement bool
+	query             string
+	replacement       string
+	regexp            bool
+	caseSensitive     bool
+	// where the cursor was before searching, to go back when cancelled
+	origin  int
+	pattern *regexp.Regexp
+	err     error
+	matches []match
+	current int
+}
+
+// a match in runes, with the byte offsets of its submatches in the text
+type match struct {
+	start      int
+	end        int
+	submatches []int
+}
+
+func (s *search) open(replacing bool) {
+	s.active = true
+	s.replacing = replacing
+	s.typingReplacement = false
+	// the selection is searched for, if any
+	s.query = s.text.selectedText()
+	s.replacement = ""
+	s.origin = s.text.cursorIndex
+	if start, _, ok := s.text.selection(); ok {
+		s.origin = start
+	}
+	s.text.clearSelection()
+	s.update(s.origin)
+}
+
+func (s *search) close() {
+	s.active = false
+	s.text.highlights = nil
+}
+
+func (s *search) cancel() {
+	s.close()
+	s.text.setCursorIndex(s.origin)
+}
+
+// update finds the matches again, going to the first one from index
+func (s *search) update(index int) {
+	s.matches = nil
+	s.current = -1
+	s.pattern, s.err = s.compile()
+	if s.pattern != nil {
+		s.matches = findMatches(s.pattern, s.text.text())
+	}
+
+	s.text.highlights = nil
+	for i, m := range s.matches {
+		s.text.highlights = append(s.text.highlights, [2]int{m.start, m.end})
+		if s.current < 0 && m.start >= index {
+			s.current = i
+		}
+	}
+	if s.current < 0 && len(s.matches) > 0 {
+		s.current = 0
+	}
+	s.show()
+}
+
+func (s *search) compile() (*regexp.Regexp, error) {
+	if s.query == "" {
+		return nil, nil
+	}
+	expression := s.query
+	if !s.regexp {
+		expression = regexp.QuoteMeta(expression)
+	}
+	if !s.caseSensitive {
+		expression = "(?i)" + expression
+	}
+	return regexp.Compile(expression)
+}
+
+// findMatches returns the non empty matches of the pattern, in runes
+func findMatches(pattern *regexp.Regexp, text string) (matches []match) {
+	runes, offset := 0, 0
+	for _, submatches := range pattern.FindAllStringSubmatchIndex(text, -1) {
+		if submatches[0] == submatches[1] {
+			continue
+		}
+		runes += utf8.RuneCountInString(text[offset:submatches[0]])
+		start := runes
+		runes += utf8.RuneCountInString(text[submatches[0]:submatches[1]])
+		offset = submatches[1]
+		matches = append(matches, match{start: start, end: runes, submatches: submatches})
+	}
+	return
+}
+
+func (s *search) show() {
+	if s.current >= 0 {
+		s.text.setCursorIndex(s.matches[s.current].start)
+	}
+}
+
+func (s *search) next() {
+	if !s.active {
+		s.update(s.text.cursorIndex + 1)
+		s.text.highlights = nil
+		return
+	}
+	if len(s.matches) > 0 {
+		s.current = (s.current + 1) % len(s.matches)
+		s.show()
+	}
+}
+
+func (s *search) previous() {
+	if !s.active {
+		s.update(s.text.cursorIndex)
+		s.text.highlights = nil
+	}
+	if len(s.matches) > 0 {
+		s.current = (s.current + len(s.matches) - 1) % len(s.matches)
+		s.show()
+	}
+}
+
+// expand returns the replacement of a match, with $1 and the like replaced by
+// the submatches in regular expression mode
+func (s *search) expand(text string, m match) string {
+	if !s.regexp {
+		return s.replacement
+	}
+	return string(s.pattern.ExpandString(nil, s.replacement, text, m.submatches))
+}
+
+func (s *search) replaceOne() {
+	if s.current < 0 {
+		return
+	}
+	m := s.matches[s.current]
+	inserted := s.expand(s.text.text(), m)
+	s.text.edit(editReplace, m.start, m.end-m.start, inserted)
+	s.update(m.start + utils.LenString(inserted))
+}
+
+func (s *search) replaceAll() {
+	if len(s.matches) == 0 {
+		return
+	}
+	text := s.text.text()
+	ranges := make([][2]int, len(s.matches))
+	replacements := make([]string, len(s.matches))
+	for i, m := range s.matches {
+		ranges[i] = [2]int{m.start, m.end}
+		replacements[i] = s.expand(text, m)
+	}
+	s.text.replaceRanges(ranges, replacements)
+	s.update(s.text.cursorIndex)
+}
+
+func (s *search) toggleRegexp() {
+	s.regexp = !s.regexp
+	s.update(s.origin)
+}
+
+func (s *search) toggleCase() {
+	s.caseSensitive = !s.caseSensitive
+	s.update(s.origin)
+}
+
+// onEvent edits the prompt, the other keys running the search commands
+func (s *search) onEvent(e event.KeyEvent) {
+	field := &s.query
+	if s.typingReplacement {
+		field = &s.replacement
+	}
+
+	switch event.Command(e) {
+	case "open-menu":
+		s.cancel()
+	case "newline":
+		if s.replacing {
+			s.replaceOne()
+		} else {
+			s.close()
+		}
+	case "search-next":
+		s.next()
+	case "search-previous":
+		s.previous()
+	case "replace-all":
+		if s.replacing {
+			s.replaceAll()
+		}
+	case "toggle-regexp":
+		s.toggleRegexp()
+	case "toggle-case":
+		s.toggleCase()
+	case "delete-backward":
+		if *field != "" {
+			runes := []rune(*field)
+			*field = string(runes[:len(runes)-1])
+			s.update(s.origin)
+		}
+	case "paste":
+		// the prompt holding one line, only the first one is pasted
+		if text, ok := clipboard.top(); ok {
+			*field += strings.SplitN(text, "\n", 2)[0]
+			s.update(s.origin)
+		}
+	default:
+		// unbound shortcuts do not type their character
+		shortcut := e.IsCtrl() || e.IsAlt() || e.IsMeta()
+		if e.KeyValue == "KEY_TAB" && s.replacing {
+			s.typingReplacement = !s.typingReplacement
+		} else if e.IsChar && e.KeyChar != "" && !shortcut {
+			*field += e.KeyChar
+			s.update(s.origin)
+		} else if e.KeyValue == "KEY_SPACE" {
+			*field += " "
+			s.update(s.origin)
+		}
+	}
+}
+
+// renderPrompt renders the prompt line, the field being typed ending with _
+func (s *search) renderPrompt(width int) matrix.Matrix {
+	query, replacement := s.query, s.replacement
+	if s.typingReplacement {
+		replacement += "_"
+	} else {
+		query += "_"
+	}
+
+	prompt := "Find: " + query
+	if s.replacing {
+		prompt = "Replace: " + query + "  With: " + replacement
+	}
+
+	switch {
+	case s.err != nil:
+		prompt += "  invalid expression"
+	case s.query == "":
+	case len(s.matches) == 0:
+		prompt += "  no match"
+	default:
+		prompt += fmt.Sprintf("  %d/%d", s.current+1, len(s.matches))
+	}
+	if s.caseSensitive {
+		prompt += "  Aa"
+	}
+	if s.regexp {
+		prompt += "  .*"
+	}
+
+	promptMatrix := matrix.CreateNewMatrix(width, 1)
+	for x, char := range []rune(prompt) {
+		if x < width {
+			promptMatrix[0][x].Content = char
+		}
+	}
+	return matrix.InverseMatrix(promptMatrix)
+}
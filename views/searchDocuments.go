@@ -0,0 +1,131 @@
+package views
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/asaskevich/EventBus"
+	"github.com/olup/kobowriter/event"
+	"github.com/olup/kobowriter/matrix"
+	"github.com/olup/kobowriter/screener"
+	"github.com/olup/kobowriter/utils"
+)
+
+// where the next document mounted puts its cursor, when opened from a hit
+type documentPosition struct {
+	lock  sync.Mutex
+	path  string
+	index int
+}
+
+var openAt documentPosition
+
+func (p *documentPosition) set(path string, index int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.path = path
+	p.index = index
+}
+
+func (p *documentPosition) take(path string) (int, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.path == "" || p.path != path {
+		return 0, false
+	}
+	p.path = ""
+	return p.index, true
+}
+
+// SearchDocuments searches every document of the save location as the query
+// is typed, and opens the one of the chosen hit at its place
+func SearchDocuments(screen *screener.Screen, bus EventBus.Bus, saveLocation string) func() {
+	index := loadIndex(saveLocation)
+	title := "Search Documents"
+	// the title, the query and a blank line come before the hits
+	maxHits := screen.Height - 6
+
+	query := ""
+	var hits []documentHit
+	selected := 0
+
+	onKey := func(e event.KeyEvent) {
+		switch {
+		case event.MenuCommand(e) == "menu-up":
+			if selected > 0 {
+				selected--
+			}
+		case event.MenuCommand(e) == "menu-down":
+			if selected < len(hits)-1 {
+				selected++
+			}
+		case event.MenuCommand(e) == "menu-select":
+			if selected < len(hits) {
+				hit := hits[selected]
+				config := utils.LoadConfig(saveLocation)
+				config.LastOpenedDocument = hit.path
+				utils.SaveConfig(config, saveLocation)
+
+				openAt.set(hit.path, hit.index)
+				bus.Publish("ROUTING", "document")
+				return
+			}
+		case event.Command(e) == "open-menu":
+			bus.Publish("ROUTING", "menu")
+			return
+		case event.Command(e) == "delete-backward":
+			if query != "" {
+				runes := []rune(query)
+				query = string(runes[:len(runes)-1])
+				hits, selected = index.search(query, maxHits), 0
+			}
+		case e.IsChar && e.KeyChar != "":
+			query += e.KeyChar
+			hits, selected = index.search(query, maxHits), 0
+		case e.KeyValue == "KEY_SPACE":
+			query += " "
+			hits, selected = index.search(query, maxHits), 0
+		}
+
+		matrixx := screen.GetOriginalMatrix()
+		matrixx = matrix.PasteMatrix(matrixx, matrix.CreateMatrixFromText(title+"\n"+strings.Repeat("=", utils.LenString(title)), utils.LenString(title)), 4, 1)
+		prompt := "> " + query + "_"
+		matrixx = matrix.PasteMatrix(matrixx, matrix.CreateMatrixFromText(prompt, utils.LenString(prompt)), 4, 3)
+
+		if query != "" && len(hits) == 0 {
+			matrixx = matrix.PasteMatrix(matrixx, matrix.CreateMatrixFromText("No match", 8), 4, 5)
+		}
+		for i, hit := range hits {
+			label := hitLabel(hit, screen.Width-8)
+			hitMatrix := matrix.CreateMatrixFromText(label, utils.LenString(label))
+			if selected == i {
+				hitMatrix = matrix.InverseMatrix(hitMatrix)
+			}
+			matrixx = matrix.PasteMatrix(matrixx, hitMatrix, 4, 5+i)
+		}
+
+		screen.Print(matrixx)
+	}
+
+	bus.SubscribeAsync("KEY", onKey, true)
+
+	// display
+	bus.Publish("KEY", event.KeyEvent{})
+
+	return func() {
+		bus.Unsubscribe("KEY", onKey)
+	}
+}
+
+// hitLabel is the title of the document then the snippet, on one line
+func hitLabel(hit documentHit, width int) string {
+	title := []rune(hit.title)
+	if len(title) > 20 {
+		title = append(title[:20], []rune("...")...)
+	}
+	label := []rune(string(title) + ": " + strings.ReplaceAll(hit.snippet, "\t", " "))
+	if len(label) > width {
+		label = append(label[:width-3], []rune("...")...)
+	}
+	return string(label)
+}
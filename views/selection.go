@@ -0,0 +1,173 @@
+package views
+
+import (
+	"unicode"
+
+	"github.com/olup/kobowriter/matrix"
+)
+
+// selection returns the selected range, start included and end excluded
+func (t *TextView) selection() (start int, end int, ok bool) {
+	if !t.selecting || t.anchor == t.cursorIndex {
+		return 0, 0, false
+	}
+	if t.anchor < t.cursorIndex {
+		return t.anchor, t.cursorIndex, true
+	}
+	return t.cursorIndex, t.anchor, true
+}
+
+func (t *TextView) selectedText() string {
+	start, end, ok := t.selection()
+	if !ok {
+		return ""
+	}
+	return t.buffer.slice(start, end)
+}
+
+// extendSelection moves the cursor with move, selecting from where it was
+func (t *TextView) extendSelection(move func()) {
+	if !t.selecting {
+		t.selecting = true
+		t.anchor = t.cursorIndex
+	}
+	move()
+}
+
+func (t *TextView) clearSelection() {
+	t.selecting = false
+}
+
+func (t *TextView) selectAll() {
+	t.selecting = true
+	t.anchor = 0
+	t.setCursorIndex(t.length())
+}
+
+func (t *TextView) deleteSelection() bool {
+	start, end, ok := t.selection()
+	if !ok {
+		return false
+	}
+	t.edit(editDelete, start, end-start, "")
+	return true
+}
+
+// renderSelection inverts the selected and highlighted cells of the lines in
+// view, back to normal in inverted headings
+func (t *TextView) renderSelection(textMatrix matrix.Matrix) {
+	ranges := t.highlights
+	if start, end, ok := t.selection(); ok {
+		ranges = append([][2]int{{start, end}}, ranges...)
+	}
+	if len(ranges) == 0 {
+		return
+	}
+
+	t.eachCell(textMatrix, func(cell *matrix.MatrixElement, index int) {
+		for _, r := range ranges {
+			if index >= r[0] && index < r[1] {
+				cell.IsInverted = !cell.IsInverted
+				break
+			}
+		}
+	})
+}
+
+func (t *TextView) moveBy(count int) {
+	t.setCursorIndex(t.cursorIndex + count)
+}
+
+func (t *TextView) moveLines(count int) {
+	t.setCursorPos(Position{
+		x: t.cursorPos.x,
+		y: t.cursorPos.y + count,
+	})
+}
+
+// moveWordLeft goes to the start of the word before the cursor
+func (t *TextView) moveWordLeft() {
+	t.setCursorIndex(t.wordLeft(t.cursorIndex))
+}
+
+// moveWordRight goes to the start of the word after the cursor
+func (t *TextView) moveWordRight() {
+	t.setCursorIndex(t.wordRight(t.cursorIndex))
+}
+
+func (t *TextView) wordLeft(index int) int {
+	for index > 0 && unicode.IsSpace(t.buffer.at(index-1)) {
+		index--
+	}
+	for index > 0 && !unicode.IsSpace(t.buffer.at(index-1)) {
+		index--
+	}
+	return index
+}
+
+func (t *TextView) wordRight(index int) int {
+	for index < t.length() && !unicode.IsSpace(t.buffer.at(index)) {
+		index++
+	}
+	for index < t.length() && unicode.IsSpace(t.buffer.at(index)) {
+		index++
+	}
+	return index
+}
+
+func (t *TextView) moveLineStart() {
+	t.setCursorPos(Position{x: 0, y: t.cursorPos.y})
+}
+
+// moveLineEnd goes before the space or new line ending the wrapped line
+func (t *TextView) moveLineEnd() {
+	t.setCursorPos(Position{x: t.lineIndent[t.cursorPos.y] + t.lineCount[t.cursorPos.y] - 1, y: t.cursorPos.y})
+}
+
+func (t *TextView) moveDocumentStart() {
+	t.setCursorIndex(0)
+}
+
+func (t *TextView) moveDocumentEnd() {
+	t.setCursorIndex(t.length())
+}
+
+// paragraphBounds returns the start of the paragraph holding index, and the
+// end where its new line is
+func (t *TextView) paragraphBounds(index int) (start int, end int) {
+	for _, p := range t.paragraphs {
+		end = start + p.length
+		if end >= index {
+			break
+		}
+		start = end + 1
+	}
+	return
+}
+
+// moveParagraphUp goes to the start of the paragraph, or of the previous one
+// when already there
+func (t *TextView) moveParagraphUp() {
+	start := 0
+	for _, p := range t.paragraphs {
+		end := start + p.length + 1
+		if end >= t.cursorIndex {
+			break
+		}
+		start = end
+	}
+	t.setCursorIndex(start)
+}
+
+// moveParagraphDown goes to the start of the next paragraph, or to the end of
+// the document after the last one
+func (t *TextView) moveParagraphDown() {
+	start := 0
+	for _, p := range t.paragraphs {
+		start += p.length + 1
+		if start > t.cursorIndex {
+			break
+		}
+	}
+	t.setCursorIndex(start)
+}
@@ -0,0 +1,178 @@
+package views
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/asaskevich/EventBus"
+	"github.com/olup/kobowriter/screener"
+	"github.com/olup/kobowriter/utils"
+)
+
+// a pomodoro is a sprint of 25 minutes then a break of 5, again and again
+const pomodoroSprint = 25 * time.Minute
+const pomodoroBreak = 5 * time.Minute
+
+// sprintState is the sprint going on, kept while the views change. A sprint
+// lasts a duration or until a count of words is written.
+type sprintState struct {
+	lock     sync.Mutex
+	running  bool
+	pomodoro bool
+	breaking bool
+	start    time.Time
+	duration time.Duration
+	target   int
+	words    int
+	// the time of the last update, for the status to follow the ticks
+	now time.Time
+}
+
+var sprint sprintState
+
+func (s *sprintState) begin(now time.Time, duration time.Duration, target int, pomodoro bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.running = true
+	s.pomodoro = pomodoro
+	s.breaking = false
+	s.start = now
+	s.duration = duration
+	s.target = target
+	s.words = 0
+	s.now = now
+}
+
+func (s *sprintState) stop() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.running = false
+}
+
+func (s *sprintState) isRunning() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.running
+}
+
+// record counts words added, or removed when negative, during the sprint
+func (s *sprintState) record(words int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.running && !s.breaking {
+		s.words += words
+	}
+}
+
+// update ends the sprint or the break when its time is up or its words are
+// written, returning the result of the sprint ended and a message about it
+func (s *sprintState) update(now time.Time) (result *utils.SprintResult, message string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !s.running {
+		return nil, ""
+	}
+
+	s.now = now
+	elapsed := now.Sub(s.start)
+	if s.breaking {
+		if elapsed >= pomodoroBreak {
+			s.breaking = false
+			s.start = now
+			s.words = 0
+			return nil, "Break over, back to writing!"
+		}
+		return nil, ""
+	}
+
+	timeUp := s.duration > 0 && elapsed >= s.duration
+	written := s.target > 0 && s.words >= s.target
+	if !timeUp && !written {
+		return nil, ""
+	}
+
+	if timeUp {
+		elapsed = s.duration
+	}
+	result = &utils.SprintResult{
+		Start:    s.start,
+		Duration: int((elapsed + time.Minute/2) / time.Minute),
+		Target:   s.target,
+		Words:    s.words,
+	}
+	message = fmt.Sprintf("Sprint over: %s in %s", plural(result.Words, "word"), plural(result.Duration, "minute"))
+
+	if s.pomodoro {
+		s.breaking = true
+		s.start = now
+		message += ", take a break"
+	} else {
+		s.running = false
+	}
+	return
+}
+
+// status shows the time left and the words written, in minutes not to redraw
+// the screen every second
+func (s *sprintState) status() string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !s.running {
+		return ""
+	}
+
+	elapsed := s.now.Sub(s.start)
+	if s.breaking {
+		return fmt.Sprintf("Break %s left", minutesLeft(pomodoroBreak-elapsed))
+	}
+	if s.target > 0 {
+		return fmt.Sprintf("Sprint %d/%d words, %d min", s.words, s.target, int(elapsed/time.Minute))
+	}
+	return fmt.Sprintf("Sprint %s left, %s", minutesLeft(s.duration-elapsed), plural(s.words, "word"))
+}
+
+// minutesLeft rounds up, the last minute showing as 1 min
+func minutesLeft(left time.Duration) string {
+	return fmt.Sprintf("%d min", int((left+time.Minute-time.Nanosecond)/time.Minute))
+}
+
+// SprintMenu starts sprints of a duration or to a count of words, or stops
+// the one going on
+func SprintMenu(screen *screener.Screen, bus EventBus.Bus) func() {
+	starting := func(duration time.Duration, target int, pomodoro bool) func() {
+		return func() {
+			sprint.begin(time.Now(), duration, target, pomodoro)
+			bus.Publish("ROUTING", "document")
+		}
+	}
+
+	options := []Option{
+		{
+			label: "Back",
+			action: func() {
+				bus.Publish("ROUTING", "menu")
+			},
+		},
+	}
+	if sprint.isRunning() {
+		options = append(options, Option{
+			label: "Stop the sprint",
+			action: func() {
+				sprint.stop()
+				bus.Publish("ROUTING", "document")
+			},
+		})
+	}
+	options = append(options,
+		Option{label: "15 minutes", action: starting(15*time.Minute, 0, false)},
+		Option{label: "30 minutes", action: starting(30*time.Minute, 0, false)},
+		Option{label: "1 hour", action: starting(time.Hour, 0, false)},
+		Option{label: "250 words", action: starting(0, 250, false)},
+		Option{label: "500 words", action: starting(0, 500, false)},
+		Option{label: "1000 words", action: starting(0, 1000, false)},
+		Option{label: "Pomodoro, 25 minutes then a 5 minute break", action: starting(pomodoroSprint, 0, true)},
+	)
+
+	return createMenu("Writing Sprint", options)(screen, bus)
+}
@@ -0,0 +1,39 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+)
+
+// words read in a minute, to estimate the reading time
+const readingSpeed = 230
+
+func countWords(text string) int {
+	return len(strings.Fields(text))
+}
+
+// statusBar shows the counts of the document, the place of the cursor in its
+// paragraphs and the words selected
+func (t *TextView) statusBar() string {
+	line, start := 0, 0
+	for line < len(t.paragraphs)-1 && start+t.paragraphs[line].length < t.cursorIndex {
+		start += t.paragraphs[line].length + 1
+		line++
+	}
+
+	minutes := (t.words + readingSpeed - 1) / readingSpeed
+	bar := fmt.Sprintf("%s  %s  Ln %d, Col %d  %d min read",
+		plural(t.words, "word"), plural(t.length(), "char"), line+1, t.cursorIndex-start+1, minutes)
+
+	if selected := t.selectedText(); selected != "" {
+		bar = fmt.Sprintf("%s selected  %s", plural(countWords(selected), "word"), bar)
+	}
+	return bar
+}
+
+func plural(count int, name string) string {
+	if count == 1 {
+		return fmt.Sprintf("1 %s", name)
+	}
+	return fmt.Sprintf("%d %ss", count, name)
+}
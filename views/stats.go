@@ -0,0 +1,107 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/asaskevich/EventBus"
+	"github.com/olup/kobowriter/event"
+	"github.com/olup/kobowriter/matrix"
+	"github.com/olup/kobowriter/screener"
+	"github.com/olup/kobowriter/utils"
+)
+
+// the chart shows the words written in each of the last days
+const chartDays = 14
+const chartHeight = 8
+
+var dailyGoals = []int{0, 250, 500, 750, 1000, 1500, 2000}
+
+// Stats shows the words written today, the streaks and a chart of the last
+// days, any key going back to the menu
+func Stats(screen *screener.Screen, bus EventBus.Bus, saveLocation string) func() {
+	onKey := func(e event.KeyEvent) {
+		bus.Publish("ROUTING", "menu")
+	}
+
+	stats := utils.LoadStats(saveLocation)
+	goal := utils.LoadConfig(saveLocation).DailyGoal
+	today := time.Now()
+
+	title := "Stats"
+	lines := []string{
+		title,
+		strings.Repeat("=", utils.LenString(title)),
+		"",
+		"Today: " + plural(stats.Written(today.Format(utils.DayLayout)), "word"),
+		fmt.Sprintf("Streak: %s, best %s", plural(stats.Streak(today, goal), "day"), plural(stats.BestStreak(goal), "day")),
+	}
+	if goal > 0 {
+		lines[3] += fmt.Sprintf(", goal %d", goal)
+	}
+	if len(stats.Sprints) > 0 {
+		last := stats.Sprints[len(stats.Sprints)-1]
+		lines = append(lines, fmt.Sprintf("Sprints: %d, the last one %s in %s",
+			len(stats.Sprints), plural(last.Words, "word"), plural(last.Duration, "minute")))
+	}
+	lines = append(lines, "")
+	lines = append(lines, chart(stats, today, goal)...)
+
+	matrixx := screen.GetOriginalMatrix()
+	for i, line := range lines {
+		matrixx = matrix.PasteMatrix(matrixx, matrix.CreateMatrixFromText(line, utils.LenString(line)+1), 4, 1+i)
+	}
+	screen.Print(matrixx)
+
+	bus.SubscribeAsync("KEY", onKey, true)
+
+	return func() {
+		bus.Unsubscribe("KEY", onKey)
+	}
+}
+
+// chart draws a bar for each day, ending today, with the goal as a dashed line
+func chart(stats utils.Stats, today time.Time, goal int) []string {
+	written := make([]int, chartDays)
+	max := goal
+	for i := range written {
+		written[i] = stats.Written(today.AddDate(0, 0, i-chartDays+1).Format(utils.DayLayout))
+		if written[i] > max {
+			max = written[i]
+		}
+	}
+	if max <= 0 {
+		max = 1
+	}
+
+	// rows needed for a count, at least one for any word written
+	rows := func(words int) int {
+		if words <= 0 {
+			return 0
+		}
+		return (words*chartHeight + max - 1) / max
+	}
+
+	lines := make([]string, 0, chartHeight+1)
+	for row := chartHeight; row > 0; row-- {
+		line := ""
+		for _, words := range written {
+			switch {
+			case rows(words) >= row:
+				line += "## "
+			case goal > 0 && rows(goal) == row:
+				line += "---"
+			default:
+				line += "   "
+			}
+		}
+		lines = append(lines, strings.TrimRight(line, " "))
+	}
+
+	days := ""
+	for i := range written {
+		days += today.AddDate(0, 0, i-chartDays+1).Format("02 ")
+	}
+	return append(lines, strings.TrimRight(days, " "))
+}
@@ -1,22 +1,36 @@
 package views
 
 import (
-	"strings"
-	"unicode/utf8"
-
 	"github.com/olup/kobowriter/matrix"
 	"github.com/olup/kobowriter/utils"
 )
 
 type TextView struct {
-	content     string
+	buffer      *gapBuffer
 	width       int
 	height      int
-	wrapContent []string
+	paragraphs  []paragraph
 	cursorIndex int
 	cursorPos   Position
-	lineCount   []int
-	scroll      int
+	// runes of each wrapped line, with the space or new line ending it
+	lineCount []int
+	// columns left blank before each wrapped line
+	lineIndent []int
+	scroll     int
+	// counts the replacements, to know when the content changed
+	changes int
+	// kept up to date with the paragraphs
+	words   int
+	history history
+	journal *journal
+	// the selection goes from the anchor to the cursor
+	selecting bool
+	anchor    int
+	// ranges shown inverted, like the matches of a search
+	highlights [][2]int
+	typewriter bool
+	focus      string
+	markdown   bool
 }
 
 type Position struct {
@@ -24,19 +38,53 @@ type Position struct {
 	y int
 }
 
-func (t *TextView) init(width int) {
-	t.width = width
+func (t *TextView) setContent(text string) {
+	t.buffer = newGapBuffer(text)
+	t.paragraphs, t.lineCount, t.lineIndent = wrapParagraphs(text, t.width, t.markdown)
+	t.words = 0
+	for _, p := range t.paragraphs {
+		t.words += p.words
+	}
+}
+
+func (t *TextView) text() string {
+	return t.buffer.String()
 }
 
-func (t *TextView) setContent(text string) {
-	t.content = text
-	t.wrapContent = strings.Split(utils.WrapText(text, t.width), "\n")
+func (t *TextView) length() int {
+	return t.buffer.len()
+}
+
+// replace replaces count runes at index by text, wrapping again the paragraphs
+// it touches
+func (t *TextView) replace(index int, count int, text string) {
+	// the paragraphs from first to last hold the replaced runes
+	first, start, line := 0, 0, 0
+	for first < len(t.paragraphs)-1 && start+t.paragraphs[first].length < index {
+		start += t.paragraphs[first].length + 1
+		line += t.paragraphs[first].lines
+		first++
+	}
+	last, end, lines := first, start+t.paragraphs[first].length, t.paragraphs[first].lines
+	t.words -= t.paragraphs[first].words
+	for last < len(t.paragraphs)-1 && end < index+count {
+		last++
+		end += t.paragraphs[last].length + 1
+		lines += t.paragraphs[last].lines
+		t.words -= t.paragraphs[last].words
+	}
+
+	t.buffer.replace(index, count, text)
+	t.changes++
 
-	lineCount := []int{}
-	for _, line := range t.wrapContent {
-		lineCount = append(lineCount, utf8.RuneCountInString(line)+1)
+	end += utils.LenString(text) - count
+	paragraphs, lineCount, lineIndent := wrapParagraphs(t.buffer.slice(start, end), t.width, t.markdown)
+	for _, p := range paragraphs {
+		t.words += p.words
 	}
-	t.lineCount = lineCount
+	t.paragraphs = spliceParagraphs(t.paragraphs, first, last+1, paragraphs)
+	t.lineCount = spliceInts(t.lineCount, line, line+lines, lineCount)
+	t.lineIndent = spliceInts(t.lineIndent, line, line+lines, lineIndent)
 }
 
 func (t *TextView) setCursorIndex(index int) {
@@ -45,12 +93,19 @@ func (t *TextView) setCursorIndex(index int) {
 	if index < 0 {
 		index = 0
 	}
-	if index > utils.LenString(t.content) {
-		index = utils.LenString(t.content)
+	if index > t.length() {
+		index = t.length()
 	}
 
 	// Processing
 	t.cursorIndex = index
+	t.cursorPos = t.indexToPos(index)
+
+	t.updateScroll()
+
+}
+
+func (t *TextView) indexToPos(index int) Position {
 	x := 0
 	y := 0
 
@@ -58,21 +113,18 @@ func (t *TextView) setCursorIndex(index int) {
 
 	for i, count := range t.lineCount {
 		aggNext := count + agg
-		if aggNext > t.cursorIndex {
+		if aggNext > index {
 			y = i
-			x = t.cursorIndex - agg
+			x = t.lineIndent[i] + index - agg
 			break
 		}
 		agg = aggNext
 	}
 
-	t.cursorPos = Position{
+	return Position{
 		x,
 		y,
 	}
-
-	t.updateScroll()
-
 }
 
 func (t *TextView) setCursorPos(position Position) {
@@ -81,16 +133,18 @@ func (t *TextView) setCursorPos(position Position) {
 		position.y = 0
 	}
 
-	if position.x < 0 {
-		position.x = 0
-	}
-
 	if position.y > len(t.lineCount)-1 {
 		position.y = len(t.lineCount) - 1
 	}
 
-	if t.lineCount[position.y]-1 < position.x {
-		position.x = t.lineCount[position.y] - 1
+	// x counts the columns of the screen, the indent before the runes
+	indent := t.lineIndent[position.y]
+	if position.x < indent {
+		position.x = indent
+	}
+
+	if t.lineCount[position.y]-1 < position.x-indent {
+		position.x = indent + t.lineCount[position.y] - 1
 	}
 
 	// Procesing
@@ -101,7 +155,7 @@ func (t *TextView) setCursorPos(position Position) {
 		agg += t.lineCount[i]
 	}
 
-	agg += position.x
+	agg += position.x - indent
 
 	t.cursorPos = position
 	t.cursorIndex = agg
@@ -109,32 +163,234 @@ func (t *TextView) setCursorPos(position Position) {
 
 }
 
+// renderMatrix renders the lines in view only
 func (t *TextView) renderMatrix() matrix.Matrix {
-	textMatrix := matrix.CreateMatrixFromText(t.content, t.width)
-	if t.cursorPos.x >= 0 && t.cursorPos.y >= 0 && t.cursorPos.x < t.width {
-		textMatrix[t.cursorPos.y][t.cursorPos.x].IsInverted = true
-	}
 	endBound := t.scroll + t.height
-	if endBound > len(textMatrix) {
-		endBound = len(textMatrix)
+	if endBound > len(t.lineCount) {
+		endBound = len(t.lineCount)
 	}
-	scrolledTextMatrix := textMatrix[t.scroll:endBound]
-	return scrolledTextMatrix
+	textMatrix := matrix.CreateNewMatrix(t.width, endBound-t.scroll)
+
+	t.eachCell(textMatrix, func(cell *matrix.MatrixElement, index int) {
+		if index < t.length() && t.buffer.at(index) != '\n' {
+			cell.Content = t.buffer.at(index)
+		}
+	})
+	t.renderMarkdown(textMatrix)
+	t.renderFocus(textMatrix)
+	t.renderSelection(textMatrix)
+
+	// the cursor inverts its cell, to show on inverted headings too, but is
+	// part of the selection it starts
+	y := t.cursorPos.y - t.scroll
+	if t.cursorPos.x >= 0 && y >= 0 && y < len(textMatrix) && t.cursorPos.x < t.width {
+		cell := &textMatrix[y][t.cursorPos.x]
+		if start, end, ok := t.selection(); ok && t.cursorIndex >= start && t.cursorIndex < end {
+			cell.IsInverted = true
+		} else {
+			cell.IsInverted = !cell.IsInverted
+		}
+	}
+	return textMatrix
+}
+
+// eachCell calls fn with the cells of the lines in view and the index of
+// their rune, after the indent of the line, the cell after the last rune
+// standing for the space or new line it was wrapped at. Lines before the text, in typewriter mode, are
+// left out.
+func (t *TextView) eachCell(textMatrix matrix.Matrix, fn func(cell *matrix.MatrixElement, index int)) {
+	first := t.scroll
+	if first < 0 {
+		first = 0
+	}
+	index := t.lineStart(first)
+	for y := first - t.scroll; y < len(textMatrix); y++ {
+		lineCount, indent := t.lineCount[t.scroll+y], t.lineIndent[t.scroll+y]
+		for x := 0; x < lineCount && indent+x < t.width; x++ {
+			fn(&textMatrix[y][indent+x], index+x)
+		}
+		index += lineCount
+	}
+}
+
+// lineStart returns the index of the first rune of a wrapped line
+func (t *TextView) lineStart(line int) (index int) {
+	for _, count := range t.lineCount[:line] {
+		index += count
+	}
+	return
 }
 
 func (t *TextView) updateScroll() {
 	y := t.cursorPos.y
 
+	// the line of the cursor stays in the middle of the screen
+	if t.typewriter {
+		t.scroll = y - t.height/2
+		return
+	}
+
 	if y > t.scroll+t.height-1 {
 		t.scroll = y - 5
 	}
 	if y < t.scroll {
 		t.scroll = y - t.height + 5
 	}
-	if t.scroll > len(t.wrapContent) {
-		t.scroll = len(t.wrapContent) - 5
+	if t.scroll > len(t.lineCount) {
+		t.scroll = len(t.lineCount) - 5
 	}
 	if t.scroll < 0 {
 		t.scroll = 0
 	}
 }
+
+// edit replaces count characters at index by inserted, moving the cursor after
+// the insertion, and records it so it can be undone
+func (t *TextView) edit(kind editKind, index int, count int, inserted string) {
+	e := edit{
+		kind:         kind,
+		index:        index,
+		deleted:      t.buffer.slice(index, index+count),
+		inserted:     inserted,
+		cursorBefore: t.cursorIndex,
+		cursorAfter:  index + utils.LenString(inserted),
+	}
+	t.selecting = false
+	t.replace(index, count, inserted)
+	t.setCursorIndex(e.cursorAfter)
+	grouped := t.history.record(e)
+	if t.journal != nil {
+		t.journal.recordEdit(e, grouped, t.text, &t.history)
+	}
+}
+
+// insert replaces the selection, if any
+func (t *TextView) insert(kind editKind, text string) {
+	if start, end, ok := t.selection(); ok {
+		t.edit(editReplace, start, end-start, text)
+		return
+	}
+	t.edit(kind, t.cursorIndex, 0, text)
+}
+
+func (t *TextView) deleteBackward() {
+	if t.deleteSelection() {
+		return
+	}
+	if t.cursorIndex > 0 {
+		t.edit(editDelete, t.cursorIndex-1, 1, "")
+	}
+}
+
+func (t *TextView) deleteForward() {
+	if t.deleteSelection() {
+		return
+	}
+	if t.cursorIndex < t.length() {
+		t.edit(editDelete, t.cursorIndex, 1, "")
+	}
+}
+
+// replaceRanges replaces sorted ranges that do not overlap by texts, as a single
+// step of the history
+func (t *TextView) replaceRanges(ranges [][2]int, texts []string) {
+	t.selecting = false
+	var edits []edit
+	for i := len(ranges) - 1; i >= 0; i-- {
+		index, count := ranges[i][0], ranges[i][1]-ranges[i][0]
+		e := edit{
+			kind:         editReplace,
+			index:        index,
+			deleted:      t.buffer.slice(index, index+count),
+			inserted:     texts[i],
+			cursorBefore: t.cursorIndex,
+			cursorAfter:  index + utils.LenString(texts[i]),
+		}
+		t.replace(index, count, texts[i])
+		t.setCursorIndex(e.cursorAfter)
+		t.history.push(e, len(edits) > 0)
+		edits = append(edits, e)
+	}
+	t.history.seal()
+	if t.journal != nil && len(edits) > 0 {
+		t.journal.recordStep(edits, t.text, &t.history)
+	}
+}
+
+func (t *TextView) deleteWordBackward() {
+	if t.deleteSelection() {
+		return
+	}
+	if start := t.wordLeft(t.cursorIndex); start < t.cursorIndex {
+		t.edit(editDelete, start, t.cursorIndex-start, "")
+	}
+}
+
+func (t *TextView) deleteWordForward() {
+	if t.deleteSelection() {
+		return
+	}
+	if end := t.wordRight(t.cursorIndex); end > t.cursorIndex {
+		t.edit(editDelete, t.cursorIndex, end-t.cursorIndex, "")
+	}
+}
+
+// deleteLineEnd deletes up to the end of the wrapped line into the clipboard,
+// or joins the next paragraph when already there
+func (t *TextView) deleteLineEnd() {
+	end := t.cursorIndex - t.cursorPos.x + t.lineIndent[t.cursorPos.y] + t.lineCount[t.cursorPos.y] - 1
+	if end == t.cursorIndex {
+		if end < t.length() && t.buffer.at(end) == '\n' {
+			t.edit(editDelete, end, 1, "")
+		}
+		return
+	}
+	clipboard.push(t.buffer.slice(t.cursorIndex, end))
+	t.edit(editDelete, t.cursorIndex, end-t.cursorIndex, "")
+}
+
+// deleteParagraph deletes the paragraph of the cursor into the clipboard
+func (t *TextView) deleteParagraph() {
+	start, end := t.paragraphBounds(t.cursorIndex)
+	clipboard.push(t.buffer.slice(start, end))
+	// with the new line after it, or before it for the last paragraph
+	if end < t.length() {
+		end++
+	} else if start > 0 {
+		start--
+	}
+	if end > start {
+		t.edit(editDelete, start, end-start, "")
+	}
+}
+
+func (t *TextView) undo() {
+	s, ok := t.history.popUndo()
+	if !ok {
+		return
+	}
+	t.selecting = false
+	for i := len(s) - 1; i >= 0; i-- {
+		e := s[i]
+		t.replace(e.index, utils.LenString(e.inserted), e.deleted)
+	}
+	t.setCursorIndex(s[0].cursorBefore)
+	if t.journal != nil {
+		t.journal.recordUndo()
+	}
+}
+
+func (t *TextView) redo() {
+	s, ok := t.history.popRedo()
+	if !ok {
+		return
+	}
+	t.selecting = false
+	for _, e := range s {
+		t.replace(e.index, utils.LenString(e.deleted), e.inserted)
+	}
+	t.setCursorIndex(s[len(s)-1].cursorAfter)
+	if t.journal != nil {
+		t.journal.recordRedo()
+	}
+}
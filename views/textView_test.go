@@ -0,0 +1,106 @@
+package views
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asaskevich/EventBus"
+	"github.com/olup/kobowriter/event"
+	"github.com/olup/kobowriter/screener"
+)
+
+var benchmarkSizes = []int{1000, 10000, 100000}
+
+// BenchmarkTyping measures a keystroke in the middle of documents of growing
+// sizes, as the document view handles it: editing, journaling the edit and
+// drawing, which should stay about the same
+func BenchmarkTyping(b *testing.B) {
+	for _, words := range benchmarkSizes {
+		b.Run(fmt.Sprintf("%d words", words), func(b *testing.B) {
+			bus := openBenchmarkDocument(b, words)
+
+			// a word is typed then deleted, for the document to keep its size
+			word := []rune("word ")
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if i%10 < len(word) {
+					char := string(word[i%10])
+					bus.Publish("KEY", event.KeyEvent{IsChar: true, KeyChar: char, KeyValue: char})
+				} else {
+					bus.Publish("KEY", event.KeyEvent{KeyValue: "KEY_BACKSPACE"})
+				}
+				bus.WaitAsync()
+			}
+		})
+	}
+}
+
+func BenchmarkMoving(b *testing.B) {
+	for _, words := range benchmarkSizes {
+		b.Run(fmt.Sprintf("%d words", words), func(b *testing.B) {
+			bus := openBenchmarkDocument(b, words)
+
+			keys := []string{"KEY_DOWN", "KEY_UP"}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				bus.Publish("KEY", event.KeyEvent{KeyValue: keys[i%2]})
+				bus.WaitAsync()
+			}
+		})
+	}
+}
+
+// BenchmarkSaving measures the save done once typing pauses
+func BenchmarkSaving(b *testing.B) {
+	for _, words := range benchmarkSizes {
+		b.Run(fmt.Sprintf("%d words", words), func(b *testing.B) {
+			bus := openBenchmarkDocument(b, words)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				bus.Publish("KEY", event.KeyEvent{IsChar: true, KeyChar: "a", KeyValue: "a"})
+				bus.Publish("KEY", event.KeyEvent{KeyValue: "KEY_BACKSPACE"})
+				bus.Publish("TICK", time.Now().Add(SaveDelay))
+				bus.WaitAsync()
+			}
+		})
+	}
+}
+
+// openBenchmarkDocument opens a document of a count of words in the document
+// view, with the cursor in the middle
+func openBenchmarkDocument(b *testing.B, words int) EventBus.Bus {
+	saveLocation := b.TempDir()
+	documentPath := filepath.Join(saveLocation, "benchmark.txt")
+	content := benchmarkText(words)
+	if err := os.WriteFile(documentPath, []byte(content), 0644); err != nil {
+		b.Fatal(err)
+	}
+
+	bus := EventBus.New()
+	screen := screener.InitScreen(screener.NewHeadless(60, 24))
+	openAt.set(documentPath, len([]rune(content))/2)
+	unmount := Document(screen, bus, saveLocation, documentPath)
+	bus.WaitAsync()
+	b.Cleanup(unmount)
+	return bus
+}
+
+// paragraphs of a hundred words of various lengths
+func benchmarkText(words int) string {
+	vocabulary := strings.Fields("the quick brown fox jumps over a lazy dog while writing chapters of an unfinished novel")
+	var text strings.Builder
+	for i := 0; i < words; i++ {
+		text.WriteString(vocabulary[i%len(vocabulary)])
+		if i%100 == 99 {
+			text.WriteString("\n")
+		} else {
+			text.WriteString(" ")
+		}
+	}
+	return text.String()
+}